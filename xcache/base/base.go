@@ -0,0 +1,197 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package base
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Evict reason
+const (
+	Expired EvictReason = iota + 1
+	Deleted
+	Flushed
+	Capacity
+	Replaced
+)
+
+// NoExpire is ttl of key never expire
+const NoExpire time.Duration = -1
+
+// EvictReason is reason of key removed from cache
+type EvictReason int
+
+// EvictFunc is called after a key is removed from cache, or its value is replaced
+type EvictFunc func(key string, value interface{}, reason EvictReason)
+
+// Stats is cache statistics
+type Stats struct {
+	Hits           uint64
+	Misses         uint64
+	Sets           uint64
+	Deletes        uint64
+	Expirations    uint64
+	Evictions      uint64
+	Keys           int
+	GCRuns         uint64
+	GCDuration     time.Duration
+	GCLastDuration time.Duration
+}
+
+// Cachex is cache interface
+type Cachex interface {
+	Get(key string) interface{}
+	MGet(key ...string) []interface{}
+	Set(key string, val interface{}, ttl int64) error
+	SetWithTTL(key string, val interface{}, ttl time.Duration) error
+	SetNX(key string, val interface{}, ttl time.Duration) (bool, error)
+	Has(key string) bool
+	Del(key string) error
+	Incr(key string) error
+	Decr(key string) error
+	IncrBy(key string, delta int64) (int64, error)
+	IncrByFloat(key string, delta float64) (float64, error)
+	TTL(key string) (time.Duration, error)
+	Expire(key string, ttl time.Duration) error
+	Persist(key string) error
+	Keys(pattern string) ([]string, error)
+	SetWithTags(key string, val interface{}, ttl time.Duration, tags ...string) error
+	InvalidateTag(tag string) error
+	Namespace(name string) Cachex
+	SetGC(gcInterval, gcMaxOnce int)
+	OnEvict(f EvictFunc)
+	Stats() Stats
+	Flush() error
+	Close() error
+}
+
+// Recorder is recording cache statistics, it is safe for concurrent use
+type Recorder struct {
+	hits           atomic.Uint64
+	misses         atomic.Uint64
+	sets           atomic.Uint64
+	deletes        atomic.Uint64
+	expirations    atomic.Uint64
+	evictions      atomic.Uint64
+	gcRuns         atomic.Uint64
+	gcDuration     atomic.Int64
+	gcLastDuration atomic.Int64
+}
+
+// Version returns package version
+func Version() string {
+	return "0.1.0"
+}
+
+// Author returns package author
+func Author() string {
+	return "[Li Kexian](https://www.likexian.com/)"
+}
+
+// License returns package license
+func License() string {
+	return "Licensed under the Apache License 2.0"
+}
+
+// Hit record a hit or miss
+func (r *Recorder) Hit(hit bool) {
+	if hit {
+		r.hits.Add(1)
+	} else {
+		r.misses.Add(1)
+	}
+}
+
+// Set record a set
+func (r *Recorder) Set() {
+	r.sets.Add(1)
+}
+
+// Delete record a delete
+func (r *Recorder) Delete() {
+	r.deletes.Add(1)
+}
+
+// Expire record n keys expired
+func (r *Recorder) Expire(n int) {
+	r.expirations.Add(uint64(n))
+}
+
+// Evict record n keys evicted due to capacity
+func (r *Recorder) Evict(n int) {
+	r.evictions.Add(uint64(n))
+}
+
+// GC record a gc run
+func (r *Recorder) GC(d time.Duration) {
+	r.gcRuns.Add(1)
+	r.gcDuration.Add(int64(d))
+	r.gcLastDuration.Store(int64(d))
+}
+
+// Stats returns recorded statistics
+func (r *Recorder) Stats() Stats {
+	return Stats{
+		Hits:           r.hits.Load(),
+		Misses:         r.misses.Load(),
+		Sets:           r.sets.Load(),
+		Deletes:        r.deletes.Load(),
+		Expirations:    r.expirations.Load(),
+		Evictions:      r.evictions.Load(),
+		GCRuns:         r.gcRuns.Load(),
+		GCDuration:     time.Duration(r.gcDuration.Load()),
+		GCLastDuration: time.Duration(r.gcLastDuration.Load()),
+	}
+}
+
+// Merge add counters of o to s, the last gc duration is the max one
+func (s *Stats) Merge(o Stats) {
+	s.Hits += o.Hits
+	s.Misses += o.Misses
+	s.Sets += o.Sets
+	s.Deletes += o.Deletes
+	s.Expirations += o.Expirations
+	s.Evictions += o.Evictions
+	s.Keys += o.Keys
+	s.GCRuns += o.GCRuns
+	s.GCDuration += o.GCDuration
+	if o.GCLastDuration > s.GCLastDuration {
+		s.GCLastDuration = o.GCLastDuration
+	}
+}
+
+// String returns reason name
+func (r EvictReason) String() string {
+	switch r {
+	case Expired:
+		return "Expired"
+	case Deleted:
+		return "Deleted"
+	case Flushed:
+		return "Flushed"
+	case Capacity:
+		return "Capacity"
+	case Replaced:
+		return "Replaced"
+	default:
+		return "Unknown"
+	}
+}
@@ -0,0 +1,163 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package base
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/likexian/gokit/assert"
+)
+
+func TestVersion(t *testing.T) {
+	assert.Contains(t, Version(), ".")
+	assert.Contains(t, Author(), "likexian")
+	assert.Contains(t, License(), "Apache License")
+}
+
+func TestEvictReason(t *testing.T) {
+	assert.Equal(t, Expired.String(), "Expired")
+	assert.Equal(t, Deleted.String(), "Deleted")
+	assert.Equal(t, Flushed.String(), "Flushed")
+	assert.Equal(t, Capacity.String(), "Capacity")
+	assert.Equal(t, Replaced.String(), "Replaced")
+	assert.Equal(t, EvictReason(0).String(), "Unknown")
+}
+
+func TestRecorder(t *testing.T) {
+	r := &Recorder{}
+	r.Hit(true)
+	r.Hit(false)
+	r.Hit(false)
+	r.Set()
+	r.Delete()
+	r.Expire(2)
+	r.Evict(3)
+	r.GC(time.Second)
+	r.GC(2 * time.Second)
+
+	s := r.Stats()
+	assert.Equal(t, s, Stats{
+		Hits:           1,
+		Misses:         2,
+		Sets:           1,
+		Deletes:        1,
+		Expirations:    2,
+		Evictions:      3,
+		GCRuns:         2,
+		GCDuration:     3 * time.Second,
+		GCLastDuration: 2 * time.Second,
+	})
+
+	s.Merge(Stats{Hits: 1, Keys: 2, GCLastDuration: time.Second})
+	assert.Equal(t, s.Hits, uint64(2))
+	assert.Equal(t, s.Keys, 2)
+	assert.Equal(t, s.GCLastDuration, 2*time.Second)
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		out     bool
+	}{
+		{"*", "", true},
+		{"*", "user:1/x", true},
+		{"", "", true},
+		{"", "a", false},
+		{"user:*", "user:1", true},
+		{"user:*", "users:1", false},
+		{"*:1", "user:1", true},
+		{"*:1", "user:12", false},
+		{"u*r*1", "user:1", true},
+		{"u*r*2", "user:1", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-b]llo", "hbllo", true},
+		{"h[b-a]llo", "hbllo", true},
+		{"h[a-b]llo", "hcllo", false},
+		{"h[a\\]]llo", "h]llo", true},
+		{"h[]]llo", "h]llo", true},
+		{"h[ae", "h[ae", true},
+		{"h[ae", "ha", false},
+		{"h\\*llo", "h*llo", true},
+		{"h\\*llo", "hello", false},
+		{"**a", "bba", true},
+		{"a*", "a", true},
+		{"a**", "b", false},
+	}
+
+	for _, v := range tests {
+		assert.Equal(t, Match(v.pattern, v.key), v.out, v)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	for _, v := range []string{"", "user:1", "a*b?c[d]e\\f", "[^a-z]"} {
+		assert.True(t, Match(Escape(v), v))
+		assert.True(t, Match(Escape(v)+"*", v+"x"))
+	}
+
+	assert.False(t, Match(Escape("a*"), "ab"))
+	assert.False(t, Match(Escape("a?"), "ab"))
+	assert.False(t, Match(Escape("[ab]"), "a"))
+}
+
+func TestAddInt(t *testing.T) {
+	tests := []struct {
+		in    interface{}
+		delta int64
+		out   interface{}
+		r     int64
+		err   error
+	}{
+		{int8(1), 1, int8(2), 2, nil},
+		{int8(1), 1000, int8(1), 0, ErrValueOverflow},
+		{int8(math.MaxInt8), 1, int8(math.MaxInt8), 0, ErrValueOverflow},
+		{int8(math.MinInt8), -1, int8(math.MinInt8), 0, ErrValueOverflow},
+		{int8(math.MaxInt8), -1, int8(math.MaxInt8 - 1), math.MaxInt8 - 1, nil},
+		{uint8(1), 1, uint8(2), 2, nil},
+		{uint8(1), 1000, uint8(1), 0, ErrValueOverflow},
+		{uint8(math.MaxUint8), 1, uint8(math.MaxUint8), 0, ErrValueOverflow},
+		{uint8(0), -1, uint8(0), 0, ErrValueLessThanZero},
+		{uint8(math.MaxUint8), -255, uint8(0), 0, nil},
+		{int64(math.MaxInt64), 1, int64(math.MaxInt64), 0, ErrValueOverflow},
+		{int64(math.MinInt64), -1, int64(math.MinInt64), 0, ErrValueOverflow},
+		{int64(math.MaxInt64), math.MinInt64, int64(-1), -1, nil},
+		{int64(0), math.MinInt64, int64(math.MinInt64), math.MinInt64, nil},
+		{uint64(math.MaxInt64), 1, uint64(math.MaxInt64), 0, ErrValueOverflow},
+		{uint64(1), math.MinInt64, uint64(1), 0, ErrValueLessThanZero},
+		{"1", 1, "1", 0, ErrDataTypeNotSupported},
+	}
+
+	for _, v := range tests {
+		out, r, err := AddInt(v.in, v.delta)
+		assert.Equal(t, err, v.err, v)
+		assert.Equal(t, out, v.out, v)
+		if err == nil {
+			assert.Equal(t, r, v.r, v)
+		}
+	}
+}
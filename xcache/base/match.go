@@ -0,0 +1,133 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package base
+
+// Match returns key is matched by the glob pattern, it is similar to redis KEYS,
+// supports * for any string, ? for any char, [abc], [^abc], [a-z] and \ for escaping
+func Match(pattern, key string) bool {
+	p := []rune(pattern)
+	k := []rune(key)
+
+	// position to retry of the last *
+	px, kx := -1, -1
+	pi, ki := 0, 0
+	for ki < len(k) {
+		if pi < len(p) {
+			switch p[pi] {
+			case '*':
+				px, kx = pi, ki
+				pi++
+				continue
+			case '?':
+				pi++
+				ki++
+				continue
+			case '[':
+				if n, ok := matchClass(p[pi:], k[ki]); n > 0 {
+					if ok {
+						pi += n
+						ki++
+						continue
+					}
+				} else if k[ki] == '[' {
+					pi++
+					ki++
+					continue
+				}
+			case '\\':
+				if pi+1 < len(p) && p[pi+1] == k[ki] {
+					pi += 2
+					ki++
+					continue
+				}
+			default:
+				if p[pi] == k[ki] {
+					pi++
+					ki++
+					continue
+				}
+			}
+		}
+		if px < 0 {
+			return false
+		}
+		// let the last * match one more char
+		pi = px + 1
+		kx++
+		ki = kx
+	}
+
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+
+	return pi == len(p)
+}
+
+// Escape returns s with glob special chars escaped, so it is matched literally
+func Escape(s string) string {
+	r := make([]rune, 0, len(s))
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			r = append(r, '\\')
+		}
+		r = append(r, c)
+	}
+
+	return string(r)
+}
+
+// matchClass match char with class like [abc], returns length of class and matched,
+// length is 0 if the class is not closed
+func matchClass(p []rune, c rune) (int, bool) {
+	i := 1
+	negate := false
+	if i < len(p) && p[i] == '^' {
+		negate = true
+		i++
+	}
+
+	matched := false
+	for first := true; i < len(p); first = false {
+		if p[i] == ']' && !first {
+			return i + 1, matched != negate
+		}
+		lo := p[i]
+		if lo == '\\' && i+1 < len(p) {
+			i++
+			lo = p[i]
+		}
+		hi := lo
+		if i+2 < len(p) && p[i+1] == '-' && p[i+2] != ']' {
+			hi = p[i+2]
+			i += 2
+		}
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		if lo <= c && c <= hi {
+			matched = true
+		}
+		i++
+	}
+
+	return 0, false
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package base
+
+import (
+	"strings"
+	"time"
+)
+
+// NamespaceSep is separator between namespace and key
+const NamespaceSep = ":"
+
+// namespace is a view of cache with keys and tags prefixed
+type namespace struct {
+	cache  Cachex
+	prefix string
+}
+
+// NewNamespace returns a view of c with keys and tags prefixed by name and NamespaceSep,
+// keys of the view can be flushed without touching keys out of the namespace
+func NewNamespace(c Cachex, name string) Cachex {
+	return &namespace{cache: c, prefix: name + NamespaceSep}
+}
+
+// Get get value from cache
+func (n *namespace) Get(key string) interface{} {
+	return n.cache.Get(n.prefix + key)
+}
+
+// MGet get multiple value from cache
+func (n *namespace) MGet(key ...string) []interface{} {
+	ks := make([]string, len(key))
+	for i, k := range key {
+		ks[i] = n.prefix + k
+	}
+
+	return n.cache.MGet(ks...)
+}
+
+// Set set key value to cache, ttl is in seconds
+func (n *namespace) Set(key string, val interface{}, ttl int64) error {
+	return n.cache.Set(n.prefix+key, val, ttl)
+}
+
+// SetWithTTL set key value to cache, ttl <= 0 means never expire
+func (n *namespace) SetWithTTL(key string, val interface{}, ttl time.Duration) error {
+	return n.cache.SetWithTTL(n.prefix+key, val, ttl)
+}
+
+// SetNX set key value to cache only if key is not exists, returns true if set
+func (n *namespace) SetNX(key string, val interface{}, ttl time.Duration) (bool, error) {
+	return n.cache.SetNX(n.prefix+key, val, ttl)
+}
+
+// Has returns key is exists
+func (n *namespace) Has(key string) bool {
+	return n.cache.Has(n.prefix + key)
+}
+
+// Del remove key from cache
+func (n *namespace) Del(key string) error {
+	return n.cache.Del(n.prefix + key)
+}
+
+// Incr increase cache counter
+func (n *namespace) Incr(key string) error {
+	return n.cache.Incr(n.prefix + key)
+}
+
+// Decr decrease cache counter
+func (n *namespace) Decr(key string) error {
+	return n.cache.Decr(n.prefix + key)
+}
+
+// IncrBy add delta to cache counter and returns the new value
+func (n *namespace) IncrBy(key string, delta int64) (int64, error) {
+	return n.cache.IncrBy(n.prefix+key, delta)
+}
+
+// IncrByFloat add delta to float cache counter and returns the new value
+func (n *namespace) IncrByFloat(key string, delta float64) (float64, error) {
+	return n.cache.IncrByFloat(n.prefix+key, delta)
+}
+
+// TTL returns remaining ttl of key
+func (n *namespace) TTL(key string) (time.Duration, error) {
+	return n.cache.TTL(n.prefix + key)
+}
+
+// Expire set ttl of key
+func (n *namespace) Expire(key string, ttl time.Duration) error {
+	return n.cache.Expire(n.prefix+key, ttl)
+}
+
+// Persist remove ttl of key
+func (n *namespace) Persist(key string) error {
+	return n.cache.Persist(n.prefix + key)
+}
+
+// Keys returns keys of namespace matched by the glob pattern, without the prefix
+func (n *namespace) Keys(pattern string) ([]string, error) {
+	ks, err := n.cache.Keys(Escape(n.prefix) + pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, k := range ks {
+		ks[i] = strings.TrimPrefix(k, n.prefix)
+	}
+
+	return ks, nil
+}
+
+// SetWithTags set key value to cache with tags, tags are scoped to the namespace
+func (n *namespace) SetWithTags(key string, val interface{}, ttl time.Duration, tags ...string) error {
+	ts := make([]string, len(tags))
+	for i, t := range tags {
+		ts[i] = n.prefix + t
+	}
+
+	return n.cache.SetWithTags(n.prefix+key, val, ttl, ts...)
+}
+
+// InvalidateTag remove all keys of namespace carrying the tag
+func (n *namespace) InvalidateTag(tag string) error {
+	return n.cache.InvalidateTag(n.prefix + tag)
+}
+
+// Namespace returns a nested namespace
+func (n *namespace) Namespace(name string) Cachex {
+	return &namespace{cache: n.cache, prefix: n.prefix + name + NamespaceSep}
+}
+
+// SetGC do nothing, gc is managed by the underlying cache
+func (n *namespace) SetGC(gcInterval, gcMaxOnce int) {}
+
+// OnEvict do nothing, it is not supported by the view, since the underlying cache
+// holds only one func, set it on the underlying cache instead
+func (n *namespace) OnEvict(f EvictFunc) {}
+
+// Stats returns statistics of the underlying cache, keys is number of keys in namespace
+func (n *namespace) Stats() Stats {
+	s := n.cache.Stats()
+	if ks, err := n.Keys("*"); err == nil {
+		s.Keys = len(ks)
+	}
+
+	return s
+}
+
+// Flush remove all keys of namespace
+func (n *namespace) Flush() error {
+	ks, err := n.cache.Keys(Escape(n.prefix) + "*")
+	if err != nil {
+		return err
+	}
+
+	for _, k := range ks {
+		err = n.cache.Del(k)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close do nothing, the underlying cache should be closed by its owner
+func (n *namespace) Close() error {
+	return nil
+}
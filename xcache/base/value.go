@@ -0,0 +1,114 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package base
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	// ErrKeyNotExists is key not exists error
+	ErrKeyNotExists = errors.New("xcache: the key is not exists")
+	// ErrDataTypeNotSupported is data type not supported error
+	ErrDataTypeNotSupported = errors.New("xcache: data type is not supported")
+	// ErrValueLessThanZero is value less than zero error
+	ErrValueLessThanZero = errors.New("xcache: object value is less than zero")
+	// ErrValueOverflow is value overflow error
+	ErrValueOverflow = errors.New("xcache: object value is overflow")
+)
+
+// AddInt returns v add delta in the same type of v, and the result as int64,
+// error if the result overflows the type of v or int64
+func AddInt(v interface{}, delta int64) (interface{}, int64, error) {
+	switch vv := v.(type) {
+	case int:
+		r, err := addInt(int64(vv), delta, math.MinInt, math.MaxInt)
+		return int(r), r, err
+	case int8:
+		r, err := addInt(int64(vv), delta, math.MinInt8, math.MaxInt8)
+		return int8(r), r, err
+	case int16:
+		r, err := addInt(int64(vv), delta, math.MinInt16, math.MaxInt16)
+		return int16(r), r, err
+	case int32:
+		r, err := addInt(int64(vv), delta, math.MinInt32, math.MaxInt32)
+		return int32(r), r, err
+	case int64:
+		r, err := addInt(vv, delta, math.MinInt64, math.MaxInt64)
+		return r, r, err
+	case uint:
+		r, err := addUint(uint64(vv), delta, math.MaxUint)
+		return uint(r), int64(r), err
+	case uint8:
+		r, err := addUint(uint64(vv), delta, math.MaxUint8)
+		return uint8(r), int64(r), err
+	case uint16:
+		r, err := addUint(uint64(vv), delta, math.MaxUint16)
+		return uint16(r), int64(r), err
+	case uint32:
+		r, err := addUint(uint64(vv), delta, math.MaxUint32)
+		return uint32(r), int64(r), err
+	case uint64:
+		r, err := addUint(vv, delta, math.MaxUint64)
+		return r, int64(r), err
+	default:
+		return v, 0, ErrDataTypeNotSupported
+	}
+}
+
+// AddFloat returns v add delta in the same type of v, and the result as float64
+func AddFloat(v interface{}, delta float64) (interface{}, float64, error) {
+	switch vv := v.(type) {
+	case float32:
+		r := vv + float32(delta)
+		return r, float64(r), nil
+	case float64:
+		r := vv + delta
+		return r, r, nil
+	default:
+		return v, 0, ErrDataTypeNotSupported
+	}
+}
+
+// addInt returns v add delta, error if the result is out of range [lo, hi]
+func addInt(v, delta, lo, hi int64) (int64, error) {
+	r := v + delta
+	if (delta > 0 && r < v) || (delta < 0 && r > v) || r < lo || r > hi {
+		return v, ErrValueOverflow
+	}
+
+	return r, nil
+}
+
+// addUint returns v add delta, error if the result is less than zero,
+// or greater than hi or max int64, since the result is returned as int64
+func addUint(v uint64, delta int64, hi uint64) (uint64, error) {
+	if delta < 0 && uint64(-delta) > v {
+		return v, ErrValueLessThanZero
+	}
+
+	r := v + uint64(delta)
+	if (delta > 0 && r < v) || r > hi || r > math.MaxInt64 {
+		return v, ErrValueOverflow
+	}
+
+	return r, nil
+}
@@ -0,0 +1,751 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package file
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/likexian/gokit/xcache/base"
+	"github.com/likexian/gokit/xhash"
+)
+
+var (
+	// ErrKeyNotExists is key not exists error
+	ErrKeyNotExists = base.ErrKeyNotExists
+	// ErrDataTypeNotSupported is data type not supported error
+	ErrDataTypeNotSupported = base.ErrDataTypeNotSupported
+	// ErrValueLessThanZero is value less than zero error
+	ErrValueLessThanZero = base.ErrValueLessThanZero
+	// ErrValueOverflow is value overflow error
+	ErrValueOverflow = base.ErrValueOverflow
+)
+
+const (
+	// fileExt is extension of cache file
+	fileExt = ".cache"
+	// lockName is name of the lock file
+	lockName = ".lock"
+	// tmpPrefix is prefix of temp file
+	tmpPrefix = ".tmp-"
+	// tmpMaxAge is age of temp file removed by gc
+	tmpMaxAge = time.Minute
+)
+
+// Object is storing single object on disk
+type Object struct {
+	Key    string
+	Value  interface{}
+	Expire int64
+	Tags   []string
+}
+
+// header is object without value
+type header struct {
+	Key    string
+	Expire int64
+}
+
+// Objects is storing all object in a directory
+type Objects struct {
+	dir        string
+	gcInterval int
+	gcMaxOnce  int
+	gcExit     chan int
+	stats      base.Recorder
+	onEvict    atomic.Pointer[base.EvictFunc]
+	sync.RWMutex
+}
+
+// Version returns package version
+func Version() string {
+	return "0.1.0"
+}
+
+// Author returns package author
+func Author() string {
+	return "[Li Kexian](https://www.likexian.com/)"
+}
+
+// License returns package license
+func License() string {
+	return "Licensed under the Apache License 2.0"
+}
+
+// New init a new file cache storing in dir, the dir is created on the first write,
+// value of custom type must be registered by gob.Register before use
+func New(dir string) *Objects {
+	o := &Objects{
+		dir:        dir,
+		gcInterval: 60,
+		gcMaxOnce:  100,
+		gcExit:     make(chan int),
+	}
+
+	go o.gc()
+
+	return o
+}
+
+// Set set key value to cache, ttl is in seconds, ttl <= 0 means never expire
+func (o *Objects) Set(key string, val interface{}, ttl int64) error {
+	return o.SetWithTTL(key, val, time.Duration(ttl)*time.Second)
+}
+
+// SetWithTTL set key value to cache, ttl <= 0 means never expire
+func (o *Objects) SetWithTTL(key string, val interface{}, ttl time.Duration) error {
+	return o.set(&Object{Key: key, Value: val, Expire: expireAt(ttl)})
+}
+
+// SetWithTags set key value to cache with tags, keys can be removed by tag with InvalidateTag
+func (o *Objects) SetWithTags(key string, val interface{}, ttl time.Duration, tags ...string) error {
+	return o.set(&Object{Key: key, Value: val, Expire: expireAt(ttl), Tags: tags})
+}
+
+// InvalidateTag remove all keys carrying the tag from cache, it reads every file in the directory
+func (o *Objects) InvalidateTag(tag string) error {
+	vs := []*Object{}
+	err := o.locked(func() error {
+		fs, err := o.files()
+		if err != nil {
+			return err
+		}
+		for _, f := range fs {
+			v, err := readFile(f)
+			if err != nil || v.expired() || !v.tagged(tag) {
+				continue
+			}
+			err = os.Remove(f)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			o.stats.Delete()
+			vs = append(vs, v)
+		}
+		return nil
+	})
+
+	o.notify(vs, base.Deleted)
+
+	return err
+}
+
+// Namespace returns a view of cache with keys and tags prefixed by name,
+// it can be flushed without touching keys out of the namespace
+func (o *Objects) Namespace(name string) base.Cachex {
+	return base.NewNamespace(o, name)
+}
+
+// SetNX set key value to cache only if key is not exists, returns true if set
+func (o *Objects) SetNX(key string, val interface{}, ttl time.Duration) (bool, error) {
+	ok := false
+	var old *Object
+	err := o.locked(func() error {
+		_, err := o.read(key)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, ErrKeyNotExists) {
+			return err
+		}
+		ok = true
+		old = o.previous(key)
+		return o.write(&Object{Key: key, Value: val, Expire: expireAt(ttl)})
+	})
+
+	if ok && err == nil {
+		o.stats.Set()
+		o.replaced(old)
+	}
+
+	return ok && err == nil, err
+}
+
+// Get get value from cache
+func (o *Objects) Get(key string) interface{} {
+	v, err := o.read(key)
+	o.stats.Hit(err == nil)
+	if err != nil {
+		return nil
+	}
+
+	return v.Value
+}
+
+// MGet get multiple value from cache
+func (o *Objects) MGet(key ...string) []interface{} {
+	r := []interface{}{}
+	for _, k := range key {
+		r = append(r, o.Get(k))
+	}
+
+	return r
+}
+
+// Has returns key is exists
+func (o *Objects) Has(key string) bool {
+	_, err := o.read(key)
+	return err == nil
+}
+
+// Del remove key from cache
+func (o *Objects) Del(key string) error {
+	o.stats.Delete()
+
+	var v *Object
+	err := o.locked(func() error {
+		if o.onEvict.Load() != nil {
+			v, _ = o.read(key)
+		}
+		err := os.Remove(o.path(key))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	})
+
+	if err == nil && v != nil {
+		o.notify([]*Object{v}, base.Deleted)
+	}
+
+	return err
+}
+
+// Incr increase cache counter
+func (o *Objects) Incr(key string) error {
+	return o.update(key, func(v *Object) error {
+		var err error
+		v.Value, _, err = base.AddInt(v.Value, 1)
+		return err
+	})
+}
+
+// Decr decrease cache counter
+func (o *Objects) Decr(key string) error {
+	return o.update(key, func(v *Object) error {
+		var err error
+		v.Value, _, err = base.AddInt(v.Value, -1)
+		return err
+	})
+}
+
+// IncrBy add delta to cache counter and returns the new value,
+// not exists key is set to int64 0 with no expire before increase,
+// ErrValueOverflow is returned if the value overflows its type
+func (o *Objects) IncrBy(key string, delta int64) (int64, error) {
+	var r int64
+	err := o.upsert(key, int64(0), func(v *Object) error {
+		var err error
+		v.Value, r, err = base.AddInt(v.Value, delta)
+		return err
+	})
+
+	return r, err
+}
+
+// IncrByFloat add delta to float cache counter and returns the new value,
+// not exists key is set to float64 0 with no expire before increase
+func (o *Objects) IncrByFloat(key string, delta float64) (float64, error) {
+	var r float64
+	err := o.upsert(key, float64(0), func(v *Object) error {
+		var err error
+		v.Value, r, err = base.AddFloat(v.Value, delta)
+		return err
+	})
+
+	return r, err
+}
+
+// TTL returns remaining ttl of key, NoExpire if key never expire
+func (o *Objects) TTL(key string) (time.Duration, error) {
+	v, err := o.read(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if v.Expire <= 0 {
+		return base.NoExpire, nil
+	}
+
+	return time.Duration(v.Expire - time.Now().UnixNano()), nil
+}
+
+// Expire set ttl of key, key is expired at once if ttl <= 0
+func (o *Objects) Expire(key string, ttl time.Duration) error {
+	return o.update(key, func(v *Object) error {
+		v.Expire = time.Now().Add(ttl).UnixNano()
+		return nil
+	})
+}
+
+// Persist remove ttl of key, so it never expire
+func (o *Objects) Persist(key string) error {
+	return o.update(key, func(v *Object) error {
+		v.Expire = 0
+		return nil
+	})
+}
+
+// Keys returns sorted unexpired keys matched by the glob pattern
+func (o *Objects) Keys(pattern string) ([]string, error) {
+	fs, err := o.files()
+	if err != nil {
+		return nil, err
+	}
+
+	r := []string{}
+	for _, f := range fs {
+		v, err := readFile(f)
+		if err != nil || v.expired() {
+			continue
+		}
+		if base.Match(pattern, v.Key) {
+			r = append(r, v.Key)
+		}
+	}
+
+	sort.Strings(r)
+
+	return r, nil
+}
+
+// Flush empty the cache
+func (o *Objects) Flush() error {
+	vs := []*Object{}
+	err := o.locked(func() error {
+		fs, err := o.files()
+		if err != nil {
+			return err
+		}
+		for _, f := range fs {
+			if o.onEvict.Load() != nil {
+				if v, err := readFile(f); err == nil && !v.expired() {
+					vs = append(vs, v)
+				}
+			}
+			err = os.Remove(f)
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		return nil
+	})
+
+	o.notify(vs, base.Flushed)
+
+	return err
+}
+
+// OnEvict set the func called after a key is removed by this process,
+// value overwritten by Set is reported as Replaced, or Expired if it was expired
+func (o *Objects) OnEvict(f base.EvictFunc) {
+	if f == nil {
+		o.onEvict.Store(nil)
+	} else {
+		o.onEvict.Store(&f)
+	}
+}
+
+// Stats returns cache statistics of this process, keys is number of files in the cache dir
+func (o *Objects) Stats() base.Stats {
+	r := o.stats.Stats()
+	if fs, err := o.files(); err == nil {
+		r.Keys = len(fs)
+	}
+
+	return r
+}
+
+// Close stop the cache service, the cached data is kept on disk
+func (o *Objects) Close() error {
+	o.gcExit <- 1
+	return nil
+}
+
+// SetGC set gc interval and max once
+func (o *Objects) SetGC(gcInterval, gcMaxOnce int) {
+	o.Lock()
+	o.gcInterval = gcInterval
+	o.gcMaxOnce = gcMaxOnce
+	o.Unlock()
+
+	o.gcExit <- 1
+	go o.gc()
+}
+
+// gc do gc check
+func (o *Objects) gc() {
+	o.RLock()
+	gcInterval := o.gcInterval
+	o.RUnlock()
+
+	t := time.NewTicker(time.Duration(gcInterval) * time.Second)
+	for {
+		select {
+		case <-o.gcExit:
+			t.Stop()
+			return
+		case <-t.C:
+			o.RLock()
+			gcMaxOnce := o.gcMaxOnce
+			o.RUnlock()
+			start := time.Now()
+			vs := []*Object{}
+			_ = o.locked(func() error {
+				o.removeTemps()
+				vs = o.removeExpired(gcMaxOnce)
+				return nil
+			})
+			o.stats.GC(time.Since(start))
+			o.stats.Expire(len(vs))
+			o.notify(vs, base.Expired)
+		}
+	}
+}
+
+// removeExpired remove at most limit expired or truncated files, returns the removed objects,
+// files can not be decoded for other reasons, for example not registered type, are kept
+func (o *Objects) removeExpired(limit int) []*Object {
+	vs := []*Object{}
+	fs, err := o.files()
+	if err != nil {
+		return vs
+	}
+
+	n := 0
+	for _, f := range fs {
+		h, err := readHeader(f)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+			continue
+		}
+		if err == nil && !h.expired() {
+			continue
+		}
+		v, verr := readFile(f)
+		if os.Remove(f) == nil {
+			if err == nil && verr == nil {
+				vs = append(vs, v)
+			}
+			n++
+			if n >= limit {
+				break
+			}
+		}
+	}
+
+	return vs
+}
+
+// removeTemps remove temp files left by crashed writers, writes are done with the directory locked,
+// so temp files are only left by crashed writers, the recent ones are kept for platforms without flock
+func (o *Objects) removeTemps() {
+	fs, err := os.ReadDir(o.dir)
+	if err != nil {
+		return
+	}
+
+	for _, f := range fs {
+		if f.IsDir() || !strings.HasPrefix(f.Name(), tmpPrefix) {
+			continue
+		}
+		if info, err := f.Info(); err == nil && time.Since(info.ModTime()) > tmpMaxAge {
+			_ = os.Remove(filepath.Join(o.dir, f.Name()))
+		}
+	}
+}
+
+// notify call the evict func with removed objects
+func (o *Objects) notify(vs []*Object, reason base.EvictReason) {
+	f := o.onEvict.Load()
+	if f == nil {
+		return
+	}
+
+	for _, v := range vs {
+		(*f)(v.Key, v.Value, reason)
+	}
+}
+
+// set write object and report the replaced object to the evict func
+func (o *Objects) set(v *Object) error {
+	o.stats.Set()
+
+	var old *Object
+	err := o.locked(func() error {
+		old = o.previous(v.Key)
+		return o.write(v)
+	})
+
+	if err == nil {
+		o.replaced(old)
+	}
+
+	return err
+}
+
+// previous returns object of key even if it is expired, nil if it is not exists
+// or the evict func is not set, the lock must be held
+func (o *Objects) previous(key string) *Object {
+	if o.onEvict.Load() == nil {
+		return nil
+	}
+
+	v, err := readFile(o.path(key))
+	if err != nil || v.Key != key {
+		return nil
+	}
+
+	return v
+}
+
+// replaced call the evict func with the object replaced by Set, as Expired if it was expired
+func (o *Objects) replaced(v *Object) {
+	if v == nil {
+		return
+	}
+
+	if v.expired() {
+		o.notify([]*Object{v}, base.Expired)
+	} else {
+		o.notify([]*Object{v}, base.Replaced)
+	}
+}
+
+// update modify object of key by f and save it
+func (o *Objects) update(key string, f func(*Object) error) error {
+	return o.locked(func() error {
+		v, err := o.read(key)
+		if err != nil {
+			return err
+		}
+		err = f(v)
+		if err != nil {
+			return err
+		}
+		return o.write(v)
+	})
+}
+
+// upsert modify object of key by f and save it, the object is set to
+// init value with no expire if key is not exists
+func (o *Objects) upsert(key string, init interface{}, f func(*Object) error) error {
+	var old *Object
+	err := o.locked(func() error {
+		v, err := o.read(key)
+		if err != nil {
+			if !errors.Is(err, ErrKeyNotExists) {
+				return err
+			}
+			old = o.previous(key)
+			v = &Object{Key: key, Value: init}
+		}
+		err = f(v)
+		if err != nil {
+			return err
+		}
+		return o.write(v)
+	})
+
+	if err == nil {
+		o.replaced(old)
+	}
+
+	return err
+}
+
+// locked call f with the cache directory locked, it is shared by processes
+func (o *Objects) locked(f func() error) error {
+	err := os.MkdirAll(o.dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	fd, err := os.OpenFile(filepath.Join(o.dir, lockName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+
+	defer fd.Close()
+
+	err = lockFile(fd)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = unlockFile(fd)
+	}()
+
+	return f()
+}
+
+// read returns unexpired object of key
+func (o *Objects) read(key string) (*Object, error) {
+	v, err := readFile(o.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrKeyNotExists
+		}
+		return nil, err
+	}
+
+	if v.Key != key || v.expired() {
+		return nil, ErrKeyNotExists
+	}
+
+	return v, nil
+}
+
+// write write object to disk, it is written to a temp file and renamed,
+// so readers never see a partial file even if the process crashes
+func (o *Objects) write(v *Object) error {
+	buf := &bytes.Buffer{}
+	err := gob.NewEncoder(buf).Encode(v)
+	if err != nil {
+		return err
+	}
+
+	fd, err := os.CreateTemp(o.dir, tmpPrefix+"*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(fd.Name())
+
+	_, err = fd.Write(buf.Bytes())
+	if err == nil {
+		err = fd.Sync()
+	}
+
+	if e := fd.Close(); err == nil {
+		err = e
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(fd.Name(), o.path(v.Key))
+}
+
+// path returns cache file path of key
+func (o *Objects) path(key string) string {
+	return filepath.Join(o.dir, xhash.Sha1(key).Hex()+fileExt)
+}
+
+// files returns all cache files
+func (o *Objects) files() ([]string, error) {
+	fs, err := os.ReadDir(o.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	r := []string{}
+	for _, f := range fs {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), fileExt) {
+			r = append(r, filepath.Join(o.dir, f.Name()))
+		}
+	}
+
+	return r, nil
+}
+
+// expireAt returns expire time of ttl, 0 if ttl <= 0
+func expireAt(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+
+	return time.Now().Add(ttl).UnixNano()
+}
+
+// readFile read object from file
+func readFile(fname string) (*Object, error) {
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Object{}
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// readHeader read object from file without decoding the value, so it works for not registered type
+func readHeader(fname string) (*header, error) {
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &header{}
+	err = gob.NewDecoder(bytes.NewReader(data)).Decode(h)
+	if err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// expired returns object is expired
+func (h *header) expired() bool {
+	return isExpired(h.Expire)
+}
+
+// expired returns object is expired
+func (v *Object) expired() bool {
+	return isExpired(v.Expire)
+}
+
+// isExpired returns the expire time is passed, expire <= 0 means never expire
+func isExpired(expire int64) bool {
+	if expire <= 0 {
+		return false
+	}
+
+	return time.Now().UnixNano() >= expire
+}
+
+// tagged returns object is carrying the tag
+func (v *Object) tagged(tag string) bool {
+	for _, t := range v.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,375 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package file
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/likexian/gokit/assert"
+	"github.com/likexian/gokit/xcache/base"
+)
+
+func TestVersion(t *testing.T) {
+	assert.Contains(t, Version(), ".")
+	assert.Contains(t, Author(), "likexian")
+	assert.Contains(t, License(), "Apache License")
+}
+
+func TestBase(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), "cache"))
+	defer c.Close()
+
+	// has
+	nx := c.Has("x")
+	assert.False(t, nx)
+
+	// set
+	err := c.Set("x", 1, -1)
+	assert.Nil(t, err)
+
+	// check set
+	nx = c.Has("x")
+	assert.True(t, nx)
+
+	// get
+	v := c.Get("x")
+	assert.Equal(t, v, 1)
+
+	// del
+	err = c.Del("x")
+	assert.Nil(t, err)
+	err = c.Del("x")
+	assert.Nil(t, err)
+
+	// check del
+	nx = c.Has("x")
+	assert.False(t, nx)
+	v = c.Get("x")
+	assert.Equal(t, v, nil)
+
+	for i := 0; i < 10; i++ {
+		k := fmt.Sprintf("%d", i)
+		err = c.Set(k, i, 0)
+		assert.Nil(t, err)
+		assert.True(t, c.Has(k))
+	}
+
+	// get multiple key
+	vs := c.MGet("1", "2", "3")
+	assert.Len(t, vs, 3)
+	assert.Equal(t, vs[0], 1)
+	assert.Equal(t, vs[1], 2)
+	assert.Equal(t, vs[2], 3)
+
+	// flush cache
+	err = c.Flush()
+	assert.Nil(t, err)
+	v = c.Get("1")
+	assert.Equal(t, v, nil)
+
+	// get on expired key
+	err = c.Set("xx", 1, 1)
+	assert.Nil(t, err)
+	time.Sleep(1 * time.Second)
+	v = c.Get("xx")
+	assert.Equal(t, v, nil)
+
+	// not registered type
+	type custom struct{ V int }
+	err = c.Set("xx", custom{1}, 0)
+	assert.NotNil(t, err)
+}
+
+func TestPersist(t *testing.T) {
+	dir := t.TempDir()
+
+	c := New(dir)
+	err := c.Set("x", "v", 0)
+	assert.Nil(t, err)
+	err = c.Set("y", []string{"a", "b"}, 60)
+	assert.Nil(t, err)
+	c.Close()
+
+	// data survives restart
+	c = New(dir)
+	defer c.Close()
+	assert.Equal(t, c.Get("x"), "v")
+	assert.Equal(t, c.Get("y"), []string{"a", "b"})
+
+	// no temp file is left
+	fs, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	for _, f := range fs {
+		assert.NotContains(t, f.Name(), ".tmp-")
+	}
+}
+
+func TestShared(t *testing.T) {
+	dir := t.TempDir()
+
+	cs := []*Objects{}
+	for i := 0; i < 4; i++ {
+		cs = append(cs, New(dir))
+	}
+
+	err := cs[0].Set("n", 0, 0)
+	assert.Nil(t, err)
+
+	wg := sync.WaitGroup{}
+	for _, c := range cs {
+		wg.Add(1)
+		go func(c *Objects) {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				assert.Nil(t, c.Incr("n"))
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	for _, c := range cs {
+		assert.Equal(t, c.Get("n"), 100)
+		c.Close()
+	}
+}
+
+func TestGC(t *testing.T) {
+	dir := t.TempDir()
+	c := New(dir)
+	defer c.Close()
+
+	c.SetGC(1, 10)
+
+	err := c.Set("x", 1, 1)
+	assert.Nil(t, err)
+	err = c.Set("y", 1, 0)
+	assert.Nil(t, err)
+
+	// broken file is removed by gc
+	err = os.WriteFile(filepath.Join(dir, "broken"+fileExt), []byte("x"), 0644)
+	assert.Nil(t, err)
+
+	// truncated file is removed by gc
+	err = c.Set("z", 1, 0)
+	assert.Nil(t, err)
+	data, err := os.ReadFile(c.path("z"))
+	assert.Nil(t, err)
+	err = os.WriteFile(c.path("z"), data[:len(data)/2], 0644)
+	assert.Nil(t, err)
+
+	// file of not registered type is kept until expired
+	for k, ttl := range map[string]int64{"u": 0, "v": 1} {
+		err = c.Set(k, gcValue{N: 1}, ttl)
+		assert.Nil(t, err)
+		data, err = os.ReadFile(c.path(k))
+		assert.Nil(t, err)
+		err = os.WriteFile(c.path(k), bytes.Replace(data, []byte("gcValueA"), []byte("gcValueB"), 1), 0644)
+		assert.Nil(t, err)
+		assert.False(t, c.Has(k))
+	}
+
+	// temp file left by crashed writer is removed by gc
+	old := filepath.Join(dir, tmpPrefix+"old")
+	err = os.WriteFile(old, []byte("x"), 0644)
+	assert.Nil(t, err)
+	err = os.Chtimes(old, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour))
+	assert.Nil(t, err)
+	recent := filepath.Join(dir, tmpPrefix+"recent")
+	err = os.WriteFile(recent, []byte("x"), 0644)
+	assert.Nil(t, err)
+
+	nx := c.Has("x")
+	assert.True(t, nx)
+
+	time.Sleep(2100 * time.Millisecond)
+
+	nx = c.Has("x")
+	assert.False(t, nx)
+
+	fs, err := c.files()
+	assert.Nil(t, err)
+	assert.Len(t, fs, 2)
+
+	_, err = os.Stat(c.path("u"))
+	assert.Nil(t, err)
+	_, err = os.Stat(c.path("v"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(old)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(recent)
+	assert.Nil(t, err)
+}
+
+// gcValue is value registered by name
+type gcValue struct {
+	N int
+}
+
+func init() {
+	gob.RegisterName("gcValueA", gcValue{})
+}
+
+func TestIncr(t *testing.T) {
+	c := New(t.TempDir())
+	defer c.Close()
+
+	tests := []struct {
+		in  interface{}
+		out interface{}
+	}{
+		{int(0), int(1)},
+		{int32(0), int32(1)},
+		{int64(0), int64(1)},
+		{uint(0), uint(1)},
+		{uint32(0), uint32(1)},
+		{uint64(0), uint64(1)},
+	}
+
+	for _, v := range tests {
+		_ = c.Set("k", v.in, 0)
+		_ = c.Incr("k")
+		assert.Equal(t, c.Get("k"), v.out)
+	}
+
+	err := c.Incr("x")
+	assert.NotNil(t, err)
+
+	err = c.Set("x", "o", 0)
+	assert.Nil(t, err)
+
+	err = c.Incr("x")
+	assert.NotNil(t, err)
+}
+
+func TestDecr(t *testing.T) {
+	c := New(t.TempDir())
+	defer c.Close()
+
+	tests := []struct {
+		in  interface{}
+		out interface{}
+	}{
+		{int(1), int(0)},
+		{int32(1), int32(0)},
+		{int64(1), int64(0)},
+		{uint(1), uint(0)},
+		{uint32(1), uint32(0)},
+		{uint64(1), uint64(0)},
+	}
+
+	for _, v := range tests {
+		_ = c.Set("k", v.in, 0)
+		_ = c.Decr("k")
+		assert.Equal(t, c.Get("k"), v.out)
+	}
+
+	err := c.Decr("x")
+	assert.NotNil(t, err)
+
+	err = c.Set("x", "o", 0)
+	assert.Nil(t, err)
+
+	err = c.Decr("x")
+	assert.NotNil(t, err)
+
+	for _, v := range []interface{}{
+		uint(0),
+		uint32(0),
+		uint64(0),
+	} {
+		_ = c.Set("k", v, 0)
+		err = c.Decr("k")
+		assert.NotNil(t, err)
+	}
+}
+
+func TestOnEvict(t *testing.T) {
+	c := New(t.TempDir())
+	defer c.Close()
+
+	c.SetGC(1, 10)
+
+	var mu sync.Mutex
+	evicted := map[string]base.EvictReason{}
+	c.OnEvict(func(key string, val interface{}, reason base.EvictReason) {
+		mu.Lock()
+		evicted[key] = reason
+		mu.Unlock()
+	})
+
+	_ = c.Set("b", 1, 0)
+	_ = c.Set("c", 1, 1)
+	_ = c.Del("b")
+	time.Sleep(2100 * time.Millisecond)
+	_ = c.Set("d", 1, 0)
+	assert.Equal(t, c.Get("d"), 1)
+	assert.Nil(t, c.Get("x"))
+	_ = c.Flush()
+
+	mu.Lock()
+	assert.Equal(t, evicted, map[string]base.EvictReason{
+		"b": base.Deleted,
+		"c": base.Expired,
+		"d": base.Flushed,
+	})
+	mu.Unlock()
+
+	s := c.Stats()
+	assert.Equal(t, s.Hits, uint64(1))
+	assert.Equal(t, s.Misses, uint64(1))
+	assert.Equal(t, s.Sets, uint64(3))
+	assert.Equal(t, s.Deletes, uint64(1))
+	assert.Equal(t, s.Expirations, uint64(1))
+	assert.Equal(t, s.Keys, 0)
+	assert.Gt(t, s.GCRuns, uint64(0))
+
+	c.OnEvict(nil)
+}
+
+func TestOnEvictReplaced(t *testing.T) {
+	c := New(t.TempDir())
+	defer c.Close()
+
+	var evicted []string
+	c.OnEvict(func(key string, val interface{}, reason base.EvictReason) {
+		evicted = append(evicted, fmt.Sprintf("%s=%v %s", key, val, reason))
+	})
+
+	_ = c.Set("a", 1, 0)
+	_ = c.Set("a", 2, 0)
+	_ = c.SetWithTags("a", 3, 0, "x")
+	_ = c.SetWithTTL("b", 1, 50*time.Millisecond)
+	_ = c.SetWithTTL("c", int64(1), 50*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	_ = c.Set("b", 2, 0)
+	_, _ = c.IncrBy("c", 1)
+
+	ok, err := c.SetNX("a", 4, 0)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, evicted, []string{"a=1 Replaced", "a=2 Replaced", "b=1 Expired", "c=1 Expired"})
+}
@@ -0,0 +1,37 @@
+//go:build unix
+
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package file
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile lock the file by flock, it blocks until the lock is acquired
+func lockFile(fd *os.File) error {
+	return syscall.Flock(int(fd.Fd()), syscall.LOCK_EX)
+}
+
+// unlockFile unlock the file locked by lockFile
+func unlockFile(fd *os.File) error {
+	return syscall.Flock(int(fd.Fd()), syscall.LOCK_UN)
+}
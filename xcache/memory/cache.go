@@ -0,0 +1,415 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package memory
+
+import (
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/likexian/gokit/xcache/base"
+)
+
+// defaultShards is default number of shards of unbounded cache
+const defaultShards = 16
+
+// Cache is typed memory cache
+type Cache[K comparable, V any] struct {
+	shards     []*shard[K, V]
+	seed       maphash.Seed
+	gcInterval int
+	gcMaxOnce  int
+	gcNext     int
+	gcExit     chan int
+	gcLock     sync.Mutex
+	gcStats    base.Recorder
+	onEvict    atomic.Pointer[func(key K, val V, reason base.EvictReason)]
+	config     config
+	calls      map[K]*call[V]
+	failures   map[K]failure
+	loadLock   sync.Mutex
+}
+
+// WithShards set the number of shards, every shard has its own lock,
+// default is 16, or 1 if the cache is bounded, the limits are divided evenly by shards,
+// so eviction is done per shard, the number is reduced to the limits if they are less than it
+func WithShards(n int) Option {
+	return func(c *config) {
+		c.shards = n
+	}
+}
+
+// NewCache init a new typed cache, the number or bytes of keys is unlimited by default
+func NewCache[K comparable, V any](opts ...Option) *Cache[K, V] {
+	c := &Cache[K, V]{
+		seed:       maphash.MakeSeed(),
+		gcInterval: 60,
+		gcMaxOnce:  100,
+		gcExit:     make(chan int),
+		config: config{
+			policy:      LRU,
+			sizer:       estimateSize,
+			negativeTTL: time.Second,
+		},
+		calls:    map[K]*call[V]{},
+		failures: map[K]failure{},
+	}
+
+	for _, opt := range opts {
+		opt(&c.config)
+	}
+
+	n := c.config.shards
+	if n <= 0 {
+		n = defaultShards
+		if c.config.bounded() {
+			n = 1
+		}
+	}
+
+	// every shard holds at least one key or byte, since 0 means unlimited
+	if m := c.config.maxEntries; m > 0 && n > m {
+		n = m
+	}
+	if m := c.config.maxBytes; m > 0 && int64(n) > m {
+		n = int(m)
+	}
+
+	for i := 0; i < n; i++ {
+		maxEntries := divide(int64(c.config.maxEntries), n, i)
+		maxBytes := divide(c.config.maxBytes, n, i)
+		c.shards = append(c.shards, newShard[K, V](int(maxEntries), maxBytes, c.config.policy))
+	}
+
+	go c.gc()
+
+	return c
+}
+
+// Set set key value to cache, ttl <= 0 means never expire,
+// ErrValueTooLarge is returned and the old value is kept if the value is larger than the cache
+func (c *Cache[K, V]) Set(key K, val V, ttl time.Duration) error {
+	v := c.newEntry(key, val, ttl)
+	old, evicted, err := c.shard(key).set(v)
+	c.replaced(old)
+	c.notify(evicted, base.Capacity)
+
+	return err
+}
+
+// SetWithTags set key value to cache with tags, keys can be removed by tag with InvalidateTag
+func (c *Cache[K, V]) SetWithTags(key K, val V, ttl time.Duration, tags ...string) error {
+	v := c.newEntry(key, val, ttl)
+	if len(tags) > 0 {
+		v.tags = append([]string{}, tags...)
+	}
+
+	old, evicted, err := c.shard(key).set(v)
+	c.replaced(old)
+	c.notify(evicted, base.Capacity)
+
+	return err
+}
+
+// InvalidateTag remove all keys carrying the tag from cache
+func (c *Cache[K, V]) InvalidateTag(tag string) error {
+	for _, s := range c.shards {
+		vs := s.invalidate(tag)
+		for _, v := range vs {
+			c.forget(v.key)
+		}
+		c.notify(vs, base.Deleted)
+	}
+
+	return nil
+}
+
+// SetNX set key value to cache only if key is not exists, returns true if set
+func (c *Cache[K, V]) SetNX(key K, val V, ttl time.Duration) (bool, error) {
+	v := c.newEntry(key, val, ttl)
+	ok, old, evicted, err := c.shard(key).setNX(v)
+	c.replaced(old)
+	c.notify(evicted, base.Capacity)
+
+	return ok, err
+}
+
+// TTL returns remaining ttl of key, NoExpire if key never expire
+func (c *Cache[K, V]) TTL(key K) (time.Duration, error) {
+	return c.shard(key).ttl(key)
+}
+
+// Expire set ttl of key, key is expired at once if ttl <= 0
+func (c *Cache[K, V]) Expire(key K, ttl time.Duration) error {
+	return c.shard(key).setExpire(key, time.Now().Add(ttl).UnixNano())
+}
+
+// Persist remove ttl of key, so it never expire
+func (c *Cache[K, V]) Persist(key K) error {
+	return c.shard(key).setExpire(key, 0)
+}
+
+// Get get value from cache, returns false if key is not exists
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	return c.shard(key).get(key)
+}
+
+// MGet get multiple value from cache, zero value is returned if key is not exists
+func (c *Cache[K, V]) MGet(key ...K) []V {
+	r := make([]V, 0, len(key))
+	for _, k := range key {
+		v, _ := c.Get(k)
+		r = append(r, v)
+	}
+
+	return r
+}
+
+// Has returns key is exists
+func (c *Cache[K, V]) Has(key K) bool {
+	return c.shard(key).has(key)
+}
+
+// Del remove key from cache
+func (c *Cache[K, V]) Del(key K) error {
+	c.forget(key)
+	if v := c.shard(key).del(key); v != nil {
+		c.notify([]*entry[K, V]{v}, base.Deleted)
+	}
+	return nil
+}
+
+// Flush empty the cache
+func (c *Cache[K, V]) Flush() error {
+	c.forgetAll()
+	for _, s := range c.shards {
+		values := s.flush(c.config.policy)
+		if c.onEvict.Load() != nil {
+			vs := make([]*entry[K, V], 0, len(values))
+			for _, v := range values {
+				vs = append(vs, v)
+			}
+			c.notify(vs, base.Flushed)
+		}
+	}
+	return nil
+}
+
+// Close stop the cache service
+func (c *Cache[K, V]) Close() error {
+	c.gcExit <- 1
+	return c.Flush()
+}
+
+// Len returns number of keys in cache, including expired keys not yet gc
+func (c *Cache[K, V]) Len() int {
+	n := 0
+	for _, s := range c.shards {
+		s.RLock()
+		n += len(s.values)
+		s.RUnlock()
+	}
+
+	return n
+}
+
+// Bytes returns estimated bytes of keys in cache, only counted if size limited
+func (c *Cache[K, V]) Bytes() int64 {
+	n := int64(0)
+	for _, s := range c.shards {
+		s.RLock()
+		n += s.bytes
+		s.RUnlock()
+	}
+
+	return n
+}
+
+// Evicted returns number of keys evicted due to size limit
+func (c *Cache[K, V]) Evicted() uint64 {
+	return c.Stats().Evictions
+}
+
+// Expired returns number of expired keys removed by gc
+func (c *Cache[K, V]) Expired() uint64 {
+	return c.Stats().Expirations
+}
+
+// OnEvict set the func called after a key is removed, it is called without the lock,
+// value overwritten by Set is reported as Replaced, or Expired if it was expired
+func (c *Cache[K, V]) OnEvict(f func(key K, val V, reason base.EvictReason)) {
+	if f == nil {
+		c.onEvict.Store(nil)
+	} else {
+		c.onEvict.Store(&f)
+	}
+}
+
+// Stats returns cache statistics
+func (c *Cache[K, V]) Stats() base.Stats {
+	r := c.gcStats.Stats()
+	for _, s := range c.shards {
+		r.Merge(s.stats.Stats())
+		s.RLock()
+		r.Keys += len(s.values)
+		s.RUnlock()
+	}
+
+	return r
+}
+
+// SetGC set gc interval and max once
+func (c *Cache[K, V]) SetGC(gcInterval, gcMaxOnce int) {
+	c.gcLock.Lock()
+	c.gcInterval = gcInterval
+	c.gcMaxOnce = gcMaxOnce
+	c.gcLock.Unlock()
+
+	c.gcExit <- 1
+	go c.gc()
+}
+
+// each call f with copy of every unexpired entry until f returns false, f is called without the lock
+func (c *Cache[K, V]) each(f func(v *entry[K, V]) bool) {
+	for _, s := range c.shards {
+		vs := s.collect()
+		for i := range vs {
+			if !f(&vs[i]) {
+				return
+			}
+		}
+	}
+}
+
+// update replace value of key with the value returned by f
+func (c *Cache[K, V]) update(key K, f func(V) (V, error)) error {
+	evicted, err := c.shard(key).update(key, f, c.config.sizer)
+	c.notify(evicted, base.Capacity)
+	return err
+}
+
+// upsert replace value of key with the value returned by f, or set it if key is not exists
+func (c *Cache[K, V]) upsert(key K, f func(V, bool) (V, error)) error {
+	old, evicted, err := c.shard(key).upsert(key, f, c.config.sizer)
+	c.replaced(old)
+	c.notify(evicted, base.Capacity)
+	return err
+}
+
+// newEntry returns a new entry with ttl
+func (c *Cache[K, V]) newEntry(key K, val V, ttl time.Duration) *entry[K, V] {
+	v := &entry[K, V]{key: key, value: val}
+	if ttl > 0 {
+		v.expire = time.Now().Add(ttl).UnixNano()
+	}
+
+	if c.config.bounded() {
+		v.size = c.config.sizer(key, val)
+	}
+
+	return v
+}
+
+// peek returns value and expire time of key, including expired but not yet removed
+func (c *Cache[K, V]) peek(key K) (V, int64, bool) {
+	return c.shard(key).peek(key)
+}
+
+// shard returns shard of key
+func (c *Cache[K, V]) shard(key K) *shard[K, V] {
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+
+	return c.shards[maphash.Comparable(c.seed, key)%uint64(len(c.shards))]
+}
+
+// gc do gc check, shards are checked one by one and only one shard is locked at a time
+func (c *Cache[K, V]) gc() {
+	c.gcLock.Lock()
+	gcInterval := c.gcInterval
+	c.gcLock.Unlock()
+
+	t := time.NewTicker(time.Duration(gcInterval) * time.Second)
+	for {
+		select {
+		case <-c.gcExit:
+			t.Stop()
+			return
+		case <-t.C:
+			c.gcLock.Lock()
+			start := time.Now()
+			removed := []*entry[K, V]{}
+			for i := 0; i < len(c.shards) && len(removed) < c.gcMaxOnce; i++ {
+				vs := c.shards[c.gcNext].gc(c.gcMaxOnce-len(removed), c.config.stale)
+				c.gcNext = (c.gcNext + 1) % len(c.shards)
+				removed = append(removed, vs...)
+			}
+			c.gcStats.GC(time.Since(start))
+			c.gcLock.Unlock()
+			c.notify(removed, base.Expired)
+		}
+	}
+}
+
+// notify call the evict func with removed entries
+func (c *Cache[K, V]) notify(vs []*entry[K, V], reason base.EvictReason) {
+	if len(vs) == 0 {
+		return
+	}
+
+	f := c.onEvict.Load()
+	if f == nil {
+		return
+	}
+
+	for _, v := range vs {
+		(*f)(v.key, v.value, reason)
+	}
+}
+
+// replaced call the evict func with the entry replaced by Set, as Expired if it was expired
+func (c *Cache[K, V]) replaced(v *entry[K, V]) {
+	if v == nil {
+		return
+	}
+
+	if v.expired() {
+		c.notify([]*entry[K, V]{v}, base.Expired)
+	} else {
+		c.notify([]*entry[K, V]{v}, base.Replaced)
+	}
+}
+
+// divide returns part of n of the i-th shard, the remainder is given to the first shards,
+// so the parts sum to n
+func divide(n int64, shards, i int) int64 {
+	if n <= 0 {
+		return 0
+	}
+
+	r := n / int64(shards)
+	if int64(i) < n%int64(shards) {
+		r++
+	}
+
+	return r
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package memory
+
+import (
+	"testing"
+	"time"
+
+	"github.com/likexian/gokit/assert"
+	"github.com/likexian/gokit/xcache/base"
+)
+
+func TestCache(t *testing.T) {
+	c := NewCache[int, string]()
+	defer c.Close()
+
+	v, ok := c.Get(1)
+	assert.False(t, ok)
+	assert.Equal(t, v, "")
+
+	err := c.Set(1, "a", 0)
+	assert.Nil(t, err)
+	assert.True(t, c.Has(1))
+
+	v, ok = c.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, v, "a")
+
+	// stored zero value is not a miss
+	err = c.Set(2, "", 0)
+	assert.Nil(t, err)
+	v, ok = c.Get(2)
+	assert.True(t, ok)
+	assert.Equal(t, v, "")
+
+	vs := c.MGet(1, 2, 3)
+	assert.Equal(t, vs, []string{"a", "", ""})
+
+	err = c.Del(1)
+	assert.Nil(t, err)
+	assert.False(t, c.Has(1))
+	assert.Equal(t, c.Len(), 1)
+
+	// sub-second ttl
+	err = c.Set(3, "c", 100*time.Millisecond)
+	assert.Nil(t, err)
+	assert.True(t, c.Has(3))
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, c.Has(3))
+	_, ok = c.Get(3)
+	assert.False(t, ok)
+
+	err = c.Flush()
+	assert.Nil(t, err)
+	assert.Equal(t, c.Len(), 0)
+}
+
+func TestCacheSizer(t *testing.T) {
+	keys := []string{}
+	c := NewCache[int, string](WithMaxBytes(100), WithSizer(func(key string, val interface{}) int64 {
+		keys = append(keys, key)
+		return int64(len(val.(string)))
+	}))
+	defer c.Close()
+
+	err := c.Set(12, "abc", 0)
+	assert.Nil(t, err)
+	assert.Equal(t, keys, []string{"12"})
+	assert.Equal(t, c.Bytes(), int64(3))
+}
+
+func TestCacheGC(t *testing.T) {
+	c := NewCache[string, *time.Time](WithMaxEntries(10))
+	defer c.Close()
+
+	c.SetGC(1, 10)
+
+	now := time.Now()
+	err := c.Set("x", &now, 500*time.Millisecond)
+	assert.Nil(t, err)
+
+	time.Sleep(1100 * time.Millisecond)
+	assert.Equal(t, c.Len(), 0)
+	assert.Equal(t, c.Expired(), uint64(1))
+}
+
+func TestCacheTags(t *testing.T) {
+	c := NewCache[string, int](WithMaxEntries(2))
+	defer c.Close()
+
+	evicted := map[string]base.EvictReason{}
+	c.OnEvict(func(key string, val int, reason base.EvictReason) {
+		evicted[key] = reason
+	})
+
+	_ = c.SetWithTags("a", 1, 0, "t")
+	_ = c.SetWithTags("b", 2, 0, "t")
+
+	// set again without tags drops the tags
+	_ = c.Set("a", 1, 0)
+	_ = c.InvalidateTag("t")
+	assert.True(t, c.Has("a"))
+	assert.False(t, c.Has("b"))
+	assert.Equal(t, evicted["b"], base.Deleted)
+
+	// evicted key is removed from index
+	_ = c.SetWithTags("b", 2, 0, "t")
+	_ = c.SetWithTags("c", 3, 0, "t")
+	assert.False(t, c.Has("a"))
+	assert.Equal(t, len(c.shards[0].tags["t"]), 2)
+
+	_ = c.InvalidateTag("t")
+	assert.Equal(t, c.Len(), 0)
+	assert.Equal(t, len(c.shards[0].tags), 0)
+
+	_ = c.SetWithTags("d", 4, 0, "t")
+	_ = c.Flush()
+	assert.Equal(t, len(c.shards[0].tags), 0)
+}
@@ -0,0 +1,272 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package memory
+
+import (
+	"container/heap"
+	"container/list"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Eviction policy
+const (
+	LRU Policy = iota
+	LFU
+)
+
+// entryOverhead is the estimated bytes used by the bookkeeping of one key
+const entryOverhead = 64
+
+// Policy is cache eviction policy
+type Policy int
+
+// Option is cache option
+type Option func(*config)
+
+// config is storing cache options
+type config struct {
+	maxEntries  int
+	maxBytes    int64
+	policy      Policy
+	sizer       func(key, val interface{}) int64
+	shards      int
+	stale       time.Duration
+	negativeTTL time.Duration
+}
+
+// evictor tracks entry usage and picks the entry to evict
+type evictor[K comparable, V any] interface {
+	add(v *entry[K, V])
+	touch(v *entry[K, V])
+	remove(v *entry[K, V])
+	victim() *entry[K, V]
+}
+
+// lruEvictor evicts the least recently used entry
+type lruEvictor[K comparable, V any] struct {
+	list *list.List
+}
+
+// lfuEvictor evicts the least frequently used entry
+type lfuEvictor[K comparable, V any] struct {
+	heap lfuHeap[K, V]
+	tick uint64
+}
+
+// lfuHeap is min heap of entries ordered by frequency
+type lfuHeap[K comparable, V any] []*entry[K, V]
+
+// WithMaxEntries set the max number of keys, 0 means unlimited
+func WithMaxEntries(n int) Option {
+	return func(c *config) {
+		c.maxEntries = n
+	}
+}
+
+// WithMaxBytes set the max estimated bytes of keys and values, 0 means unlimited,
+// value larger than the limit of its shard is rejected with ErrValueTooLarge
+func WithMaxBytes(n int64) Option {
+	return func(c *config) {
+		c.maxBytes = n
+	}
+}
+
+// WithPolicy set the eviction policy, LRU or LFU
+func WithPolicy(p Policy) Option {
+	return func(c *config) {
+		c.policy = p
+	}
+}
+
+// WithSizer set the func for estimating bytes of key and value,
+// key of typed cache is formatted by fmt.Sprint if it is not string
+func WithSizer(f func(key string, val interface{}) int64) Option {
+	return func(c *config) {
+		c.sizer = func(key, val interface{}) int64 {
+			if k, ok := key.(string); ok {
+				return f(k, val)
+			}
+			return f(fmt.Sprint(key), val)
+		}
+	}
+}
+
+// bounded returns cache is limited by number or bytes of keys
+func (c config) bounded() bool {
+	return c.maxEntries > 0 || c.maxBytes > 0
+}
+
+// newEvictor returns evictor of policy
+func newEvictor[K comparable, V any](p Policy) evictor[K, V] {
+	if p == LFU {
+		return &lfuEvictor[K, V]{heap: lfuHeap[K, V]{}}
+	}
+
+	return &lruEvictor[K, V]{list: list.New()}
+}
+
+// add add entry to lru list
+func (e *lruEvictor[K, V]) add(v *entry[K, V]) {
+	v.elem = e.list.PushFront(v)
+}
+
+// touch move entry to the front of lru list
+func (e *lruEvictor[K, V]) touch(v *entry[K, V]) {
+	e.list.MoveToFront(v.elem)
+}
+
+// remove remove entry from lru list
+func (e *lruEvictor[K, V]) remove(v *entry[K, V]) {
+	e.list.Remove(v.elem)
+	v.elem = nil
+}
+
+// victim returns the least recently used entry
+func (e *lruEvictor[K, V]) victim() *entry[K, V] {
+	if v := e.list.Back(); v != nil {
+		return v.Value.(*entry[K, V])
+	}
+
+	return nil
+}
+
+// add add entry to lfu heap
+func (e *lfuEvictor[K, V]) add(v *entry[K, V]) {
+	e.tick++
+	v.freq = 1
+	v.tick = e.tick
+	heap.Push(&e.heap, v)
+}
+
+// touch increase entry frequency
+func (e *lfuEvictor[K, V]) touch(v *entry[K, V]) {
+	e.tick++
+	v.freq++
+	v.tick = e.tick
+	heap.Fix(&e.heap, v.index)
+}
+
+// remove remove entry from lfu heap
+func (e *lfuEvictor[K, V]) remove(v *entry[K, V]) {
+	heap.Remove(&e.heap, v.index)
+}
+
+// victim returns the least frequently used entry
+func (e *lfuEvictor[K, V]) victim() *entry[K, V] {
+	if len(e.heap) > 0 {
+		return e.heap[0]
+	}
+
+	return nil
+}
+
+// Len returns heap length
+func (h lfuHeap[K, V]) Len() int {
+	return len(h)
+}
+
+// Less returns the less used one, the older one if same frequency
+func (h lfuHeap[K, V]) Less(i, j int) bool {
+	if h[i].freq == h[j].freq {
+		return h[i].tick < h[j].tick
+	}
+
+	return h[i].freq < h[j].freq
+}
+
+// Swap swaps two entries
+func (h lfuHeap[K, V]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+// Push push entry to heap
+func (h *lfuHeap[K, V]) Push(x interface{}) {
+	v := x.(*entry[K, V])
+	v.index = len(*h)
+	*h = append(*h, v)
+}
+
+// Pop pop the last entry from heap
+func (h *lfuHeap[K, V]) Pop() interface{} {
+	old := *h
+	n := len(old)
+	v := old[n-1]
+	old[n-1] = nil
+	v.index = -1
+	*h = old[:n-1]
+	return v
+}
+
+// estimateSize returns estimated bytes of key and value
+func estimateSize(key, val interface{}) int64 {
+	return entryOverhead + sizeOf(reflect.ValueOf(key), 0) + sizeOf(reflect.ValueOf(val), 0)
+}
+
+// sizeOf returns estimated bytes of value
+func sizeOf(v reflect.Value, depth int) int64 {
+	if !v.IsValid() {
+		return 0
+	}
+
+	if depth > 8 {
+		return int64(v.Type().Size())
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Type().Size()) + int64(v.Len())
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return int64(v.Type().Size())
+		}
+		return int64(v.Type().Size()) + sizeOf(v.Elem(), depth+1)
+	case reflect.Slice, reflect.Array:
+		n := int64(0)
+		if v.Kind() == reflect.Slice {
+			n = int64(v.Type().Size())
+			if v.Type().Elem().Kind() == reflect.Uint8 {
+				return n + int64(v.Len())
+			}
+		}
+		for i := 0; i < v.Len(); i++ {
+			n += sizeOf(v.Index(i), depth+1)
+		}
+		return n
+	case reflect.Map:
+		n := int64(v.Type().Size())
+		it := v.MapRange()
+		for it.Next() {
+			n += sizeOf(it.Key(), depth+1) + sizeOf(it.Value(), depth+1)
+		}
+		return n
+	case reflect.Struct:
+		n := int64(0)
+		for i := 0; i < v.NumField(); i++ {
+			n += sizeOf(v.Field(i), depth+1)
+		}
+		return n
+	default:
+		return int64(v.Type().Size())
+	}
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package memory
+
+import (
+	"fmt"
+	"time"
+)
+
+// call is storing an in-flight or finished loader call
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// failure is storing a cached loader error
+type failure struct {
+	err    error
+	expire int64
+}
+
+// WithStale set the stale-while-revalidate window of GetOrLoad,
+// within the window after a key expired, the expired value is returned at once
+// while a background loader refresh it, 0 means disabled
+func WithStale(d time.Duration) Option {
+	return func(c *config) {
+		c.stale = d
+	}
+}
+
+// WithNegativeTTL set how long a loader error is cached by GetOrLoad, default is 1 second
+func WithNegativeTTL(d time.Duration) Option {
+	return func(c *config) {
+		c.negativeTTL = d
+	}
+}
+
+// GetOrLoad get value from cache, or load it by loader and set to cache with ttl if not exists,
+// only one loader of a key is running at the same time, others wait for its result
+func (c *Cache[K, V]) GetOrLoad(key K, ttl time.Duration, loader func() (V, error)) (V, error) {
+	v, expire, ok := c.peek(key)
+	if ok {
+		now := time.Now().UnixNano()
+		if expire <= 0 || now < expire {
+			c.shard(key).stats.Hit(true)
+			return v, nil
+		}
+		if c.config.stale > 0 && now < expire+int64(c.config.stale) {
+			if c.failed(key) == nil {
+				c.load(key, ttl, loader)
+			}
+			return v, nil
+		}
+	}
+
+	c.shard(key).stats.Hit(false)
+	if err := c.failed(key); err != nil {
+		var zero V
+		return zero, err
+	}
+
+	r := c.load(key, ttl, loader)
+	<-r.done
+
+	return r.value, r.err
+}
+
+// load start loader of key if it is not running, returns the running call
+func (c *Cache[K, V]) load(key K, ttl time.Duration, loader func() (V, error)) *call[V] {
+	c.loadLock.Lock()
+	defer c.loadLock.Unlock()
+
+	if r, ok := c.calls[key]; ok {
+		return r
+	}
+
+	r := &call[V]{done: make(chan struct{})}
+	c.calls[key] = r
+
+	go c.doLoad(key, ttl, loader, r)
+
+	return r
+}
+
+// doLoad call loader and save the result
+func (c *Cache[K, V]) doLoad(key K, ttl time.Duration, loader func() (V, error), r *call[V]) {
+	defer func() {
+		if e := recover(); e != nil {
+			r.err = fmt.Errorf("xcache: loader panic: %v", e)
+		}
+		if r.err == nil {
+			_ = c.Set(key, r.value, ttl)
+		}
+		c.loadLock.Lock()
+		if r.err != nil && c.config.negativeTTL > 0 {
+			c.failures[key] = failure{r.err, time.Now().Add(c.config.negativeTTL).UnixNano()}
+		}
+		delete(c.calls, key)
+		c.loadLock.Unlock()
+		close(r.done)
+	}()
+
+	r.value, r.err = loader()
+}
+
+// failed returns cached loader error of key
+func (c *Cache[K, V]) failed(key K) error {
+	c.loadLock.Lock()
+	defer c.loadLock.Unlock()
+
+	f, ok := c.failures[key]
+	if !ok {
+		return nil
+	}
+
+	if time.Now().UnixNano() >= f.expire {
+		delete(c.failures, key)
+		return nil
+	}
+
+	return f.err
+}
+
+// forget remove cached loader error of key
+func (c *Cache[K, V]) forget(key K) {
+	c.loadLock.Lock()
+	delete(c.failures, key)
+	c.loadLock.Unlock()
+}
+
+// forgetAll remove all cached loader errors
+func (c *Cache[K, V]) forgetAll() {
+	c.loadLock.Lock()
+	c.failures = map[K]failure{}
+	c.loadLock.Unlock()
+}
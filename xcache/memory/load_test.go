@@ -0,0 +1,137 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package memory
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/likexian/gokit/assert"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	c := NewCache[string, int]()
+	defer c.Close()
+
+	var n int32
+	loader := func() (int, error) {
+		atomic.AddInt32(&n, 1)
+		time.Sleep(100 * time.Millisecond)
+		return 1, nil
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.GetOrLoad("x", 0, loader)
+			assert.Nil(t, err)
+			assert.Equal(t, v, 1)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, atomic.LoadInt32(&n), int32(1))
+
+	// loaded value is cached
+	v, err := c.GetOrLoad("x", 0, loader)
+	assert.Nil(t, err)
+	assert.Equal(t, v, 1)
+	assert.Equal(t, atomic.LoadInt32(&n), int32(1))
+
+	// loader panic is returned as error
+	_, err = c.GetOrLoad("p", 0, func() (int, error) { panic("boom") })
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "boom")
+}
+
+func TestGetOrLoadNegativeTTL(t *testing.T) {
+	c := NewCache[string, int](WithNegativeTTL(100 * time.Millisecond))
+	defer c.Close()
+
+	var n int32
+	errFailed := errors.New("failed")
+	loader := func() (int, error) {
+		if atomic.AddInt32(&n, 1) == 1 {
+			return 0, errFailed
+		}
+		return 2, nil
+	}
+
+	_, err := c.GetOrLoad("x", 0, loader)
+	assert.Equal(t, err, errFailed)
+
+	// error is cached within negative ttl
+	_, err = c.GetOrLoad("x", 0, loader)
+	assert.Equal(t, err, errFailed)
+	assert.Equal(t, atomic.LoadInt32(&n), int32(1))
+
+	time.Sleep(100 * time.Millisecond)
+	v, err := c.GetOrLoad("x", 0, loader)
+	assert.Nil(t, err)
+	assert.Equal(t, v, 2)
+	assert.Equal(t, atomic.LoadInt32(&n), int32(2))
+
+	// del forgets the cached error
+	atomic.StoreInt32(&n, 0)
+	_ = c.Del("x")
+	_, err = c.GetOrLoad("x", 0, loader)
+	assert.Equal(t, err, errFailed)
+	_ = c.Del("x")
+	v, err = c.GetOrLoad("x", 0, loader)
+	assert.Nil(t, err)
+	assert.Equal(t, v, 2)
+}
+
+func TestGetOrLoadStale(t *testing.T) {
+	c := NewCache[string, int](WithStale(time.Second))
+	defer c.Close()
+
+	var n int32
+	done := make(chan bool, 1)
+	loader := func() (int, error) {
+		v := atomic.AddInt32(&n, 1)
+		if v > 1 {
+			defer func() { done <- true }()
+		}
+		return int(v), nil
+	}
+
+	v, err := c.GetOrLoad("x", 100*time.Millisecond, loader)
+	assert.Nil(t, err)
+	assert.Equal(t, v, 1)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.False(t, c.Has("x"))
+
+	// expired value is returned at once while refreshing
+	v, err = c.GetOrLoad("x", 100*time.Millisecond, loader)
+	assert.Nil(t, err)
+	assert.Equal(t, v, 1)
+
+	<-done
+	time.Sleep(10 * time.Millisecond)
+	v, err = c.GetOrLoad("x", 100*time.Millisecond, loader)
+	assert.Nil(t, err)
+	assert.Equal(t, v, 2)
+}
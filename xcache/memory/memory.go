@@ -21,32 +21,32 @@ package memory
 
 import (
 	"errors"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/likexian/gokit/xcache/base"
 )
 
 var (
 	// ErrKeyNotExists is key not exists error
-	ErrKeyNotExists = errors.New("xcache: the key is not exists")
+	ErrKeyNotExists = base.ErrKeyNotExists
 	// ErrDataTypeNotSupported is data type not supported error
-	ErrDataTypeNotSupported = errors.New("xcache: data type is not supported")
+	ErrDataTypeNotSupported = base.ErrDataTypeNotSupported
 	// ErrValueLessThanZero is value less than zero error
-	ErrValueLessThanZero = errors.New("xcache: object value is less than zero")
+	ErrValueLessThanZero = base.ErrValueLessThanZero
+	// ErrValueTooLarge is value larger than the cache error
+	ErrValueTooLarge = errors.New("xcache: object value is larger than the cache")
+	// ErrValueOverflow is value overflow error
+	ErrValueOverflow = base.ErrValueOverflow
 )
 
-// Object is storing single object
-type Object struct {
-	value  interface{}
-	expire int64
-}
-
 // Objects is storing all object
 type Objects struct {
-	values     map[string]*Object
-	gcInterval int
-	gcMaxOnce  int
-	gcExit     chan int
-	sync.RWMutex
+	cache    *Cache[string, interface{}]
+	snapExit chan bool
+	snapDone chan bool
+	snapLock sync.Mutex
 }
 
 // Version returns package version
@@ -64,213 +64,192 @@ func License() string {
 	return "Licensed under the Apache License 2.0"
 }
 
-// New init a new cache
-func New() *Objects {
-	o := &Objects{
-		values:     map[string]*Object{},
-		gcInterval: 60,
-		gcMaxOnce:  100,
-		gcExit:     make(chan int),
+// New init a new cache, the number or bytes of keys is unlimited by default
+func New(opts ...Option) *Objects {
+	return &Objects{
+		cache: NewCache[string, interface{}](opts...),
 	}
-
-	go o.gc()
-
-	return o
 }
 
-// Set set key value to cache
+// Set set key value to cache, ttl is in seconds, ttl <= 0 means never expire
 func (o *Objects) Set(key string, val interface{}, ttl int64) error {
-	if ttl > 0 {
-		ttl = time.Now().Add(time.Duration(ttl) * time.Second).Unix()
-	}
+	return o.cache.Set(key, val, time.Duration(ttl)*time.Second)
+}
 
-	o.Lock()
-	defer o.Unlock()
-	o.values[key] = &Object{val, ttl}
+// SetWithTTL set key value to cache, ttl <= 0 means never expire
+func (o *Objects) SetWithTTL(key string, val interface{}, ttl time.Duration) error {
+	return o.cache.Set(key, val, ttl)
+}
 
-	return nil
+// SetWithTags set key value to cache with tags, keys can be removed by tag with InvalidateTag
+func (o *Objects) SetWithTags(key string, val interface{}, ttl time.Duration, tags ...string) error {
+	return o.cache.SetWithTags(key, val, ttl, tags...)
 }
 
-// Get get value from cache
-func (o *Objects) Get(key string) interface{} {
-	o.RLock()
-	defer o.RUnlock()
+// InvalidateTag remove all keys carrying the tag from cache
+func (o *Objects) InvalidateTag(tag string) error {
+	return o.cache.InvalidateTag(tag)
+}
 
-	v, ok := o.values[key]
-	if !ok {
-		return nil
-	}
+// Namespace returns a view of cache with keys and tags prefixed by name,
+// it can be flushed without touching keys out of the namespace
+func (o *Objects) Namespace(name string) base.Cachex {
+	return base.NewNamespace(o, name)
+}
 
-	if v.expired() {
-		return nil
-	}
+// SetNX set key value to cache only if key is not exists, returns true if set
+func (o *Objects) SetNX(key string, val interface{}, ttl time.Duration) (bool, error) {
+	return o.cache.SetNX(key, val, ttl)
+}
 
-	return v.value
+// Get get value from cache
+func (o *Objects) Get(key string) interface{} {
+	v, _ := o.cache.Get(key)
+	return v
 }
 
 // MGet get multiple value from cache
 func (o *Objects) MGet(key ...string) []interface{} {
-	r := []interface{}{}
-	for _, k := range key {
-		r = append(r, o.Get(k))
-	}
-
-	return r
+	return o.cache.MGet(key...)
 }
 
 // Has returns key is exists
 func (o *Objects) Has(key string) bool {
-	o.RLock()
-	defer o.RUnlock()
-	v, ok := o.values[key]
-	if !ok {
-		return false
-	}
-
-	return !v.expired()
+	return o.cache.Has(key)
 }
 
 // Del remove key from cache
 func (o *Objects) Del(key string) error {
-	o.Lock()
-	defer o.Unlock()
-	delete(o.values, key)
-	return nil
+	return o.cache.Del(key)
+}
+
+// GetOrLoad get value from cache, or load it by loader and set to cache with ttl if not exists,
+// only one loader of a key is running at the same time
+func (o *Objects) GetOrLoad(key string, ttl time.Duration, loader func() (interface{}, error)) (interface{}, error) {
+	return o.cache.GetOrLoad(key, ttl, loader)
 }
 
 // Incr increase cache counter
 func (o *Objects) Incr(key string) error {
-	o.Lock()
-	defer o.Unlock()
-
-	v, ok := o.values[key]
-	if !ok {
-		return ErrKeyNotExists
-	}
-
-	switch vv := v.value.(type) {
-	case int:
-		v.value = vv + 1
-	case int32:
-		v.value = vv + 1
-	case int64:
-		v.value = vv + 1
-	case uint:
-		v.value = vv + 1
-	case uint32:
-		v.value = vv + 1
-	case uint64:
-		v.value = vv + 1
-	default:
-		return ErrDataTypeNotSupported
-	}
-
-	return nil
+	return o.cache.update(key, func(v interface{}) (interface{}, error) {
+		v, _, err := base.AddInt(v, 1)
+		return v, err
+	})
 }
 
 // Decr decrease cache counter
 func (o *Objects) Decr(key string) error {
-	o.Lock()
-	defer o.Unlock()
+	return o.cache.update(key, func(v interface{}) (interface{}, error) {
+		v, _, err := base.AddInt(v, -1)
+		return v, err
+	})
+}
+
+// IncrBy add delta to cache counter and returns the new value,
+// not exists key is set to int64 0 with no expire before increase,
+// ErrValueOverflow is returned if the value overflows its type
+func (o *Objects) IncrBy(key string, delta int64) (int64, error) {
+	var r int64
+	err := o.cache.upsert(key, func(v interface{}, ok bool) (interface{}, error) {
+		if !ok {
+			v = int64(0)
+		}
+		v, n, err := base.AddInt(v, delta)
+		r = n
+		return v, err
+	})
 
-	v, ok := o.values[key]
-	if !ok {
-		return ErrKeyNotExists
-	}
+	return r, err
+}
 
-	switch vv := v.value.(type) {
-	case int:
-		v.value = vv - 1
-	case int32:
-		v.value = vv - 1
-	case int64:
-		v.value = vv - 1
-	case uint:
-		if vv <= 0 {
-			return ErrValueLessThanZero
-		}
-		v.value = vv - 1
-	case uint32:
-		if vv <= 0 {
-			return ErrValueLessThanZero
+// IncrByFloat add delta to float cache counter and returns the new value,
+// not exists key is set to float64 0 with no expire before increase
+func (o *Objects) IncrByFloat(key string, delta float64) (float64, error) {
+	var r float64
+	err := o.cache.upsert(key, func(v interface{}, ok bool) (interface{}, error) {
+		if !ok {
+			v = float64(0)
 		}
-		v.value = vv - 1
-	case uint64:
-		if vv <= 0 {
-			return ErrValueLessThanZero
+		v, n, err := base.AddFloat(v, delta)
+		r = n
+		return v, err
+	})
+
+	return r, err
+}
+
+// TTL returns remaining ttl of key, NoExpire if key never expire
+func (o *Objects) TTL(key string) (time.Duration, error) {
+	return o.cache.TTL(key)
+}
+
+// Expire set ttl of key, key is expired at once if ttl <= 0
+func (o *Objects) Expire(key string, ttl time.Duration) error {
+	return o.cache.Expire(key, ttl)
+}
+
+// Persist remove ttl of key, so it never expire
+func (o *Objects) Persist(key string) error {
+	return o.cache.Persist(key)
+}
+
+// Keys returns sorted unexpired keys matched by the glob pattern
+func (o *Objects) Keys(pattern string) ([]string, error) {
+	r := []string{}
+	o.cache.each(func(v *entry[string, interface{}]) bool {
+		if base.Match(pattern, v.key) {
+			r = append(r, v.key)
 		}
-		v.value = vv - 1
-	default:
-		return ErrDataTypeNotSupported
-	}
+		return true
+	})
 
-	return nil
+	sort.Strings(r)
+
+	return r, nil
 }
 
 // Flush empty the cache
 func (o *Objects) Flush() error {
-	o.Lock()
-	defer o.Unlock()
-	o.values = map[string]*Object{}
-	return nil
+	return o.cache.Flush()
 }
 
-// Close stop the cache service
+// Close stop the cache service, keys are saved if snapshot is set
 func (o *Objects) Close() error {
-	o.gcExit <- 1
-	o.Flush()
-	return nil
+	o.stopSnapshot()
+	return o.cache.Close()
 }
 
-// SetGC set gc interval and max once
-func (o *Objects) SetGC(gcInterval, gcMaxOnce int) {
-	o.Lock()
-	o.gcInterval = gcInterval
-	o.gcMaxOnce = gcMaxOnce
-	o.Unlock()
-
-	o.gcExit <- 1
-	go o.gc()
-}
-
-// gc do gc check
-func (o *Objects) gc() {
-	o.RLock()
-	gcInterval := o.gcInterval
-	o.RUnlock()
-
-	t := time.NewTicker(time.Duration(gcInterval) * time.Second)
-	for {
-		select {
-		case <-o.gcExit:
-			t.Stop()
-			return
-		case <-t.C:
-			o.RLock()
-			e := []string{}
-			for k, v := range o.values {
-				if v.expired() {
-					e = append(e, k)
-					if len(e) >= o.gcMaxOnce {
-						break
-					}
-				}
-			}
-			o.RUnlock()
-			o.Lock()
-			for _, k := range e {
-				delete(o.values, k)
-			}
-			o.Unlock()
-		}
-	}
+// Len returns number of keys in cache, including expired keys not yet gc
+func (o *Objects) Len() int {
+	return o.cache.Len()
 }
 
-// expired returns object is expired
-func (b *Object) expired() bool {
-	if b.expire <= 0 {
-		return false
-	}
+// Bytes returns estimated bytes of keys in cache, only counted if size limited
+func (o *Objects) Bytes() int64 {
+	return o.cache.Bytes()
+}
+
+// Evicted returns number of keys evicted due to size limit
+func (o *Objects) Evicted() uint64 {
+	return o.cache.Evicted()
+}
+
+// Expired returns number of expired keys removed by gc
+func (o *Objects) Expired() uint64 {
+	return o.cache.Expired()
+}
+
+// OnEvict set the func called after a key is removed
+func (o *Objects) OnEvict(f base.EvictFunc) {
+	o.cache.OnEvict(f)
+}
 
-	return time.Now().Unix() >= b.expire
+// Stats returns cache statistics
+func (o *Objects) Stats() base.Stats {
+	return o.cache.Stats()
+}
+
+// SetGC set gc interval and max once
+func (o *Objects) SetGC(gcInterval, gcMaxOnce int) {
+	o.cache.SetGC(gcInterval, gcMaxOnce)
 }
@@ -21,10 +21,13 @@ package memory
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/likexian/gokit/assert"
+	"github.com/likexian/gokit/xcache/base"
 )
 
 func TestVersion(t *testing.T) {
@@ -140,6 +143,65 @@ func TestIncr(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestIncrBy(t *testing.T) {
+	c := New()
+	defer c.Close()
+
+	_ = c.Set("k", uint8(5), 0)
+	n, err := c.IncrBy("k", 3)
+	assert.Nil(t, err)
+	assert.Equal(t, n, int64(8))
+	assert.Equal(t, c.Get("k"), uint8(8))
+
+	_, err = c.IncrBy("k", -10)
+	assert.Equal(t, err, ErrValueLessThanZero)
+	assert.Equal(t, c.Get("k"), uint8(8))
+
+	_, err = c.IncrBy("k", 1000)
+	assert.Equal(t, err, ErrValueOverflow)
+	assert.Equal(t, c.Get("k"), uint8(8))
+
+	_ = c.SetWithTTL("k", float32(1), time.Minute)
+	f, err := c.IncrByFloat("k", 0.5)
+	assert.Nil(t, err)
+	assert.Equal(t, f, 1.5)
+	assert.Equal(t, c.Get("k"), float32(1.5))
+
+	ttl, err := c.TTL("k")
+	assert.Nil(t, err)
+	assert.True(t, ttl > 0 && ttl <= time.Minute)
+
+	_, err = c.IncrBy("k", 1)
+	assert.Equal(t, err, ErrDataTypeNotSupported)
+
+	_ = c.Set("s", "o", 0)
+	_, err = c.IncrByFloat("s", 1)
+	assert.Equal(t, err, ErrDataTypeNotSupported)
+}
+
+func TestKeys(t *testing.T) {
+	c := New()
+	defer c.Close()
+
+	_ = c.Set("a:1", 1, 0)
+	_ = c.Set("a:2", 1, 0)
+	_ = c.SetWithTTL("a:3", 1, 50*time.Millisecond)
+	_ = c.Set("b:1", 1, 0)
+
+	ks, err := c.Keys("a:*")
+	assert.Nil(t, err)
+	assert.Equal(t, ks, []string{"a:1", "a:2", "a:3"})
+
+	time.Sleep(100 * time.Millisecond)
+	ks, err = c.Keys("a:*")
+	assert.Nil(t, err)
+	assert.Equal(t, ks, []string{"a:1", "a:2"})
+
+	ks, err = c.Keys("*")
+	assert.Nil(t, err)
+	assert.Equal(t, len(ks), 3)
+}
+
 func TestDecr(t *testing.T) {
 	c := New()
 	defer c.Close()
@@ -181,3 +243,289 @@ func TestDecr(t *testing.T) {
 		assert.NotNil(t, err)
 	}
 }
+
+func TestEvictLRU(t *testing.T) {
+	c := New(WithMaxEntries(3))
+	defer c.Close()
+
+	for i := 0; i < 3; i++ {
+		_ = c.Set(fmt.Sprintf("%d", i), i, 0)
+	}
+
+	// touch 0, so 1 is the least recently used
+	assert.Equal(t, c.Get("0"), 0)
+
+	_ = c.Set("3", 3, 0)
+	assert.Equal(t, c.Len(), 3)
+	assert.True(t, c.Has("0"))
+	assert.False(t, c.Has("1"))
+	assert.True(t, c.Has("2"))
+	assert.True(t, c.Has("3"))
+	assert.Equal(t, c.Evicted(), uint64(1))
+	assert.Equal(t, c.Expired(), uint64(0))
+
+	// update existing key do not evict
+	_ = c.Set("2", 22, 0)
+	assert.Equal(t, c.Len(), 3)
+	assert.Equal(t, c.Evicted(), uint64(1))
+
+	err := c.Del("3")
+	assert.Nil(t, err)
+	assert.Equal(t, c.Len(), 2)
+
+	c.Flush()
+	assert.Equal(t, c.Len(), 0)
+	assert.Equal(t, c.Bytes(), int64(0))
+}
+
+func TestEvictLFU(t *testing.T) {
+	c := New(WithMaxEntries(3), WithPolicy(LFU))
+	defer c.Close()
+
+	for i := 0; i < 3; i++ {
+		_ = c.Set(fmt.Sprintf("%d", i), i, 0)
+	}
+
+	for i := 0; i < 3; i++ {
+		_ = c.Get("0")
+		_ = c.Get("2")
+	}
+	_ = c.Get("1")
+
+	_ = c.Set("3", 3, 0)
+	assert.False(t, c.Has("1"))
+
+	// 3 is used only once, so it is evicted next
+	_ = c.Set("4", 4, 0)
+	assert.False(t, c.Has("3"))
+	assert.True(t, c.Has("0"))
+	assert.True(t, c.Has("2"))
+	assert.True(t, c.Has("4"))
+	assert.Equal(t, c.Evicted(), uint64(2))
+}
+
+func TestEvictBytes(t *testing.T) {
+	c := New(WithMaxBytes(1000), WithSizer(func(key string, val interface{}) int64 {
+		return int64(len(val.(string)))
+	}))
+	defer c.Close()
+
+	for i := 0; i < 10; i++ {
+		_ = c.Set(fmt.Sprintf("%d", i), strings.Repeat("x", 100), 0)
+	}
+	assert.Equal(t, c.Len(), 10)
+	assert.Equal(t, c.Bytes(), int64(1000))
+
+	_ = c.Set("x", strings.Repeat("x", 250), 0)
+	assert.Equal(t, c.Len(), 8)
+	assert.Equal(t, c.Bytes(), int64(950))
+	assert.False(t, c.Has("0"))
+	assert.False(t, c.Has("2"))
+	assert.True(t, c.Has("3"))
+	assert.Equal(t, c.Evicted(), uint64(3))
+
+	// value larger than the cache is not stored, and it is not an eviction
+	err := c.Set("big", strings.Repeat("x", 2000), 0)
+	assert.Equal(t, err, ErrValueTooLarge)
+	assert.False(t, c.Has("big"))
+	assert.Equal(t, c.Len(), 8)
+	assert.Equal(t, c.Evicted(), uint64(3))
+
+	// old value is kept
+	err = c.Set("x", strings.Repeat("x", 2000), 0)
+	assert.Equal(t, err, ErrValueTooLarge)
+	assert.Equal(t, c.Get("x"), strings.Repeat("x", 250))
+	assert.Equal(t, c.Bytes(), int64(950))
+
+	ok, err := c.SetNX("big", strings.Repeat("x", 2000), 0)
+	assert.Equal(t, err, ErrValueTooLarge)
+	assert.False(t, ok)
+
+	assert.Gt(t, estimateSize("x", map[string][]int{"a": {1, 2, 3}}), int64(entryOverhead))
+	assert.Gt(t, estimateSize("x", strings.Repeat("x", 100)), estimateSize("x", "x"))
+}
+
+func TestUpdateSize(t *testing.T) {
+	c := New(WithMaxEntries(10), WithSizer(func(key string, val interface{}) int64 {
+		return val.(int64)
+	}))
+	defer c.Close()
+
+	_ = c.Set("a", int64(10), 0)
+	assert.Equal(t, c.Bytes(), int64(10))
+
+	_, err := c.IncrBy("a", 5)
+	assert.Nil(t, err)
+	assert.Equal(t, c.Bytes(), int64(15))
+
+	err = c.Decr("a")
+	assert.Nil(t, err)
+	assert.Equal(t, c.Bytes(), int64(14))
+
+	// updated key is moved to the front, so it is not the next victim
+	c = New(WithMaxEntries(2))
+	defer c.Close()
+
+	var evicted []string
+	c.OnEvict(func(key string, val interface{}, reason base.EvictReason) {
+		evicted = append(evicted, key)
+	})
+
+	_ = c.Set("a", 1, 0)
+	_ = c.Set("b", 1, 0)
+	err = c.Incr("a")
+	assert.Nil(t, err)
+	_ = c.Set("c", 1, 0)
+	assert.True(t, c.Has("a"))
+	assert.False(t, c.Has("b"))
+
+	_, err = c.IncrBy("a", 1)
+	assert.Nil(t, err)
+	_ = c.Set("d", 1, 0)
+	assert.True(t, c.Has("a"))
+	assert.False(t, c.Has("c"))
+	assert.Equal(t, evicted, []string{"b", "c"})
+
+	// value larger than the cache is not reported as evicted
+	c = New(WithMaxBytes(10), WithSizer(func(key string, val interface{}) int64 {
+		return val.(int64)
+	}))
+	defer c.Close()
+
+	evicted = nil
+	c.OnEvict(func(key string, val interface{}, reason base.EvictReason) {
+		evicted = append(evicted, key)
+	})
+
+	err = c.Set("x", int64(100), 0)
+	assert.Equal(t, err, ErrValueTooLarge)
+	assert.False(t, c.Has("x"))
+
+	// value grown larger than the cache is not updated
+	_ = c.Set("x", int64(8), 0)
+	_, err = c.IncrBy("x", 5)
+	assert.Equal(t, err, ErrValueTooLarge)
+	assert.Equal(t, c.Get("x"), int64(8))
+	assert.Equal(t, c.Bytes(), int64(8))
+	assert.Len(t, evicted, 0)
+	assert.Equal(t, c.Evicted(), uint64(0))
+}
+
+func TestExpiredCount(t *testing.T) {
+	c := New(WithMaxEntries(10))
+	defer c.Close()
+
+	c.SetGC(1, 10)
+
+	_ = c.Set("x", 1, 1)
+	_ = c.Set("y", 1, 0)
+	time.Sleep(2100 * time.Millisecond)
+
+	assert.Equal(t, c.Len(), 1)
+	assert.Equal(t, c.Expired(), uint64(1))
+	assert.Equal(t, c.Evicted(), uint64(0))
+}
+
+func TestObjectsGetOrLoad(t *testing.T) {
+	c := New()
+	defer c.Close()
+
+	v, err := c.GetOrLoad("x", 0, func() (interface{}, error) { return 1, nil })
+	assert.Nil(t, err)
+	assert.Equal(t, v, 1)
+	assert.Equal(t, c.Get("x"), 1)
+
+	v, err = c.GetOrLoad("y", 100*time.Millisecond, func() (interface{}, error) { return 2, nil })
+	assert.Nil(t, err)
+	assert.Equal(t, v, 2)
+
+	ttl, err := c.TTL("y")
+	assert.Nil(t, err)
+	assert.True(t, ttl > 0 && ttl <= 100*time.Millisecond)
+}
+
+func TestOnEvict(t *testing.T) {
+	c := New(WithMaxEntries(2))
+	defer c.Close()
+
+	c.SetGC(1, 10)
+
+	var mu sync.Mutex
+	evicted := map[string]base.EvictReason{}
+	c.OnEvict(func(key string, val interface{}, reason base.EvictReason) {
+		mu.Lock()
+		evicted[key] = reason
+		mu.Unlock()
+	})
+
+	_ = c.Set("a", 1, 0)
+	_ = c.Set("b", 1, 0)
+	_ = c.Set("c", 1, 1)
+	_ = c.Del("b")
+	_ = c.Del("x")
+	time.Sleep(2100 * time.Millisecond)
+	_ = c.Set("d", 1, 0)
+	_ = c.Flush()
+
+	mu.Lock()
+	assert.Equal(t, evicted, map[string]base.EvictReason{
+		"a": base.Capacity,
+		"b": base.Deleted,
+		"c": base.Expired,
+		"d": base.Flushed,
+	})
+	mu.Unlock()
+
+	s := c.Stats()
+	assert.Equal(t, s.Sets, uint64(4))
+	assert.Equal(t, s.Deletes, uint64(1))
+	assert.Equal(t, s.Evictions, uint64(1))
+	assert.Equal(t, s.Expirations, uint64(1))
+	assert.Gt(t, s.GCRuns, uint64(0))
+
+	c.OnEvict(nil)
+	_ = c.Set("e", 1, 0)
+	_ = c.Del("e")
+}
+
+func TestStats(t *testing.T) {
+	c := New()
+	defer c.Close()
+
+	_ = c.Set("a", 1, 0)
+	_ = c.Get("a")
+	_ = c.Get("b")
+	_ = c.MGet("a", "b", "c")
+	_, _ = c.GetOrLoad("a", 0, func() (interface{}, error) { return 1, nil })
+	_, _ = c.GetOrLoad("d", 0, func() (interface{}, error) { return 1, nil })
+
+	s := c.Stats()
+	assert.Equal(t, s.Hits, uint64(3))
+	assert.Equal(t, s.Misses, uint64(4))
+	assert.Equal(t, s.Sets, uint64(2))
+	assert.Equal(t, s.Keys, 2)
+}
+
+func TestOnEvictReplaced(t *testing.T) {
+	c := New()
+	defer c.Close()
+
+	var evicted []string
+	c.OnEvict(func(key string, val interface{}, reason base.EvictReason) {
+		evicted = append(evicted, fmt.Sprintf("%s=%v %s", key, val, reason))
+	})
+
+	_ = c.Set("a", 1, 0)
+	_ = c.Set("a", 2, 0)
+	_ = c.SetWithTags("a", 3, 0, "x")
+	_ = c.SetWithTTL("b", 1, 50*time.Millisecond)
+	_ = c.SetWithTTL("c", int64(1), 50*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	_ = c.Set("b", 2, 0)
+	_, _ = c.IncrBy("c", 1)
+
+	ok, err := c.SetNX("a", 4, 0)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, evicted, []string{"a=1 Replaced", "a=2 Replaced", "b=1 Expired", "c=1 Expired"})
+}
@@ -0,0 +1,465 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package memory
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/likexian/gokit/xcache/base"
+)
+
+// shard is storing part of keys of typed cache, with its own lock
+type shard[K comparable, V any] struct {
+	values     map[K]*entry[K, V]
+	tags       map[string]map[K]struct{}
+	evictor    evictor[K, V]
+	maxEntries int
+	maxBytes   int64
+	bytes      int64
+	stats      base.Recorder
+	sync.RWMutex
+}
+
+// entry is storing single value of typed cache
+type entry[K comparable, V any] struct {
+	key    K
+	value  V
+	expire int64
+	tags   []string
+	size   int64
+	freq   uint64
+	tick   uint64
+	index  int
+	elem   *list.Element
+}
+
+// newShard returns a new shard with limits
+func newShard[K comparable, V any](maxEntries int, maxBytes int64, p Policy) *shard[K, V] {
+	s := &shard[K, V]{
+		values:     map[K]*entry[K, V]{},
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+	}
+
+	if maxEntries > 0 || maxBytes > 0 {
+		s.evictor = newEvictor[K, V](p)
+	}
+
+	return s
+}
+
+// set set entry to shard, returns the replaced entry and entries evicted due to capacity
+func (s *shard[K, V]) set(v *entry[K, V]) (*entry[K, V], []*entry[K, V], error) {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.insert(v)
+}
+
+// setNX set entry to shard if key is not exists, returns the replaced expired entry
+// and entries evicted due to capacity
+func (s *shard[K, V]) setNX(v *entry[K, V]) (bool, *entry[K, V], []*entry[K, V], error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if o, ok := s.values[v.key]; ok && !o.expired() {
+		return false, nil, nil, nil
+	}
+
+	old, evicted, err := s.insert(v)
+
+	return err == nil, old, evicted, err
+}
+
+// upsert replace value of key with the value returned by f, f is called with ok false
+// if key is not exists, and the returned value is set with no expire,
+// returns the replaced expired entry and entries evicted due to capacity
+func (s *shard[K, V]) upsert(key K, f func(V, bool) (V, error),
+	sizer func(key, val interface{}) int64) (*entry[K, V], []*entry[K, V], error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if v, ok := s.values[key]; ok && !v.expired() {
+		value, err := f(v.value, true)
+		if err != nil {
+			return nil, nil, err
+		}
+		evicted, err := s.replace(v, value, sizer)
+		return nil, evicted, err
+	}
+
+	var zero V
+	value, err := f(zero, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v := &entry[K, V]{key: key, value: value}
+	if s.evictor != nil {
+		v.size = sizer(key, value)
+	}
+
+	return s.insert(v)
+}
+
+// ttl returns remaining ttl of key
+func (s *shard[K, V]) ttl(key K) (time.Duration, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	v, ok := s.values[key]
+	if !ok || v.expired() {
+		return 0, ErrKeyNotExists
+	}
+
+	if v.expire <= 0 {
+		return base.NoExpire, nil
+	}
+
+	return time.Duration(v.expire - time.Now().UnixNano()), nil
+}
+
+// setExpire set expire time of key, 0 means never expire
+func (s *shard[K, V]) setExpire(key K, expire int64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	v, ok := s.values[key]
+	if !ok || v.expired() {
+		return ErrKeyNotExists
+	}
+
+	v.expire = expire
+
+	return nil
+}
+
+// insert insert entry to shard, returns the replaced entry and entries evicted due to capacity,
+// value larger than the shard is not stored and the old value is kept, the lock must be held
+func (s *shard[K, V]) insert(v *entry[K, V]) (*entry[K, V], []*entry[K, V], error) {
+	if s.tooLarge(v.size) {
+		return nil, nil, ErrValueTooLarge
+	}
+
+	s.stats.Set()
+
+	o, ok := s.values[v.key]
+	if ok {
+		s.remove(o)
+		if o.expired() {
+			s.stats.Expire(1)
+		}
+	}
+
+	if s.evictor == nil {
+		s.values[v.key] = v
+		s.tag(v)
+		return o, nil, nil
+	}
+
+	s.values[v.key] = v
+	s.tag(v)
+	s.bytes += v.size
+	// evict before adding, so the new key is not the victim
+	evicted := s.evict()
+	s.evictor.add(v)
+
+	return o, evicted, nil
+}
+
+// get returns unexpired value of key
+func (s *shard[K, V]) get(key K) (V, bool) {
+	if s.evictor != nil {
+		s.Lock()
+		defer s.Unlock()
+	} else {
+		s.RLock()
+		defer s.RUnlock()
+	}
+
+	v, ok := s.values[key]
+	if !ok || v.expired() {
+		s.stats.Hit(false)
+		var zero V
+		return zero, false
+	}
+
+	s.stats.Hit(true)
+	if s.evictor != nil {
+		s.evictor.touch(v)
+	}
+
+	return v.value, true
+}
+
+// peek returns value and expire time of key, including expired but not yet removed
+func (s *shard[K, V]) peek(key K) (V, int64, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	v, ok := s.values[key]
+	if !ok {
+		var zero V
+		return zero, 0, false
+	}
+
+	if s.evictor != nil {
+		s.evictor.touch(v)
+	}
+
+	return v.value, v.expire, true
+}
+
+// has returns key is exists
+func (s *shard[K, V]) has(key K) bool {
+	s.RLock()
+	defer s.RUnlock()
+
+	v, ok := s.values[key]
+	if !ok {
+		return false
+	}
+
+	return !v.expired()
+}
+
+// del remove key from shard, returns the removed entry
+func (s *shard[K, V]) del(key K) *entry[K, V] {
+	s.Lock()
+	defer s.Unlock()
+
+	v, ok := s.values[key]
+	if !ok {
+		return nil
+	}
+
+	s.stats.Delete()
+	s.remove(v)
+
+	return v
+}
+
+// flush remove all keys from shard, returns the removed entries
+func (s *shard[K, V]) flush(p Policy) map[K]*entry[K, V] {
+	s.Lock()
+	defer s.Unlock()
+
+	values := s.values
+	s.values = map[K]*entry[K, V]{}
+	s.tags = nil
+	s.bytes = 0
+	if s.evictor != nil {
+		s.evictor = newEvictor[K, V](p)
+	}
+
+	return values
+}
+
+// invalidate remove keys carrying the tag from shard, returns the removed entries
+func (s *shard[K, V]) invalidate(tag string) []*entry[K, V] {
+	s.Lock()
+	defer s.Unlock()
+
+	ks := s.tags[tag]
+	if len(ks) == 0 {
+		return nil
+	}
+
+	r := make([]*entry[K, V], 0, len(ks))
+	for k := range ks {
+		if v, ok := s.values[k]; ok {
+			s.remove(v)
+			s.stats.Delete()
+			r = append(r, v)
+		}
+	}
+
+	return r
+}
+
+// update replace value of key with the value returned by f, returns entries evicted due to capacity
+func (s *shard[K, V]) update(key K, f func(V) (V, error),
+	sizer func(key, val interface{}) int64) ([]*entry[K, V], error) {
+	s.Lock()
+	defer s.Unlock()
+
+	v, ok := s.values[key]
+	if !ok || v.expired() {
+		return nil, ErrKeyNotExists
+	}
+
+	value, err := f(v.value)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.replace(v, value, sizer)
+}
+
+// collect returns copy of unexpired entries
+func (s *shard[K, V]) collect() []entry[K, V] {
+	s.RLock()
+	defer s.RUnlock()
+
+	vs := make([]entry[K, V], 0, len(s.values))
+	for _, v := range s.values {
+		if !v.expired() {
+			vs = append(vs, *v)
+		}
+	}
+
+	return vs
+}
+
+// gc remove at most max keys expired and out of the stale window, returns the removed entries
+func (s *shard[K, V]) gc(max int, stale time.Duration) []*entry[K, V] {
+	s.RLock()
+	e := []K{}
+	for k, v := range s.values {
+		if len(e) >= max {
+			break
+		}
+		if v.dead(stale) {
+			e = append(e, k)
+		}
+	}
+	s.RUnlock()
+
+	if len(e) == 0 {
+		return nil
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	r := []*entry[K, V]{}
+	for _, k := range e {
+		if v, ok := s.values[k]; ok && v.dead(stale) {
+			s.remove(v)
+			r = append(r, v)
+		}
+	}
+
+	s.stats.Expire(len(r))
+
+	return r
+}
+
+// tag add entry to index of its tags, the lock must be held
+func (s *shard[K, V]) tag(v *entry[K, V]) {
+	if len(v.tags) == 0 {
+		return
+	}
+
+	if s.tags == nil {
+		s.tags = map[string]map[K]struct{}{}
+	}
+
+	for _, t := range v.tags {
+		if s.tags[t] == nil {
+			s.tags[t] = map[K]struct{}{}
+		}
+		s.tags[t][v.key] = struct{}{}
+	}
+}
+
+// remove remove entry from shard, the lock must be held
+func (s *shard[K, V]) remove(v *entry[K, V]) {
+	delete(s.values, v.key)
+	for _, t := range v.tags {
+		delete(s.tags[t], v.key)
+		if len(s.tags[t]) == 0 {
+			delete(s.tags, t)
+		}
+	}
+	if s.evictor != nil {
+		s.bytes -= v.size
+		s.evictor.remove(v)
+	}
+}
+
+// overflow returns shard is out of limits, the lock must be held
+func (s *shard[K, V]) overflow() bool {
+	return (s.maxEntries > 0 && len(s.values) > s.maxEntries) ||
+		(s.maxBytes > 0 && s.bytes > s.maxBytes)
+}
+
+// replace replace value of entry, update its size and mark it as used,
+// returns entries evicted due to capacity, the lock must be held
+func (s *shard[K, V]) replace(v *entry[K, V], value V, sizer func(key, val interface{}) int64) ([]*entry[K, V], error) {
+	if s.evictor == nil {
+		v.value = value
+		return nil, nil
+	}
+
+	size := sizer(v.key, value)
+	if s.tooLarge(size) {
+		return nil, ErrValueTooLarge
+	}
+
+	v.value = value
+	s.bytes += size - v.size
+	v.size = size
+	s.evictor.touch(v)
+
+	return s.evict(), nil
+}
+
+// tooLarge returns true if value of the size never fits in the shard
+func (s *shard[K, V]) tooLarge(size int64) bool {
+	return s.maxBytes > 0 && size > s.maxBytes
+}
+
+// evict remove entries until the shard is within limits, the lock must be held
+func (s *shard[K, V]) evict() []*entry[K, V] {
+	var r []*entry[K, V]
+	for s.overflow() {
+		v := s.evictor.victim()
+		if v == nil {
+			break
+		}
+		s.remove(v)
+		r = append(r, v)
+	}
+
+	s.stats.Evict(len(r))
+
+	return r
+}
+
+// expired returns entry is expired
+func (v *entry[K, V]) expired() bool {
+	if v.expire <= 0 {
+		return false
+	}
+
+	return time.Now().UnixNano() >= v.expire
+}
+
+// dead returns entry is expired and out of the stale window
+func (v *entry[K, V]) dead(stale time.Duration) bool {
+	if v.expire <= 0 {
+		return false
+	}
+
+	return time.Now().UnixNano() >= v.expire+int64(stale)
+}
@@ -0,0 +1,237 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package memory
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/likexian/gokit/assert"
+	"github.com/likexian/gokit/xcache/base"
+)
+
+func TestShards(t *testing.T) {
+	c := NewCache[string, int]()
+	defer c.Close()
+	assert.Len(t, c.shards, defaultShards)
+
+	c = NewCache[string, int](WithMaxEntries(10))
+	defer c.Close()
+	assert.Len(t, c.shards, 1)
+
+	c = NewCache[string, int](WithShards(8), WithMaxEntries(80))
+	defer c.Close()
+	assert.Len(t, c.shards, 8)
+
+	for i := 0; i < 1000; i++ {
+		_ = c.Set(strconv.Itoa(i), i, 0)
+	}
+
+	assert.Equal(t, c.Len(), 80)
+	assert.Equal(t, c.Evicted(), uint64(920))
+	for _, s := range c.shards {
+		assert.Equal(t, len(s.values), 10)
+	}
+
+	err := c.Flush()
+	assert.Nil(t, err)
+	assert.Equal(t, c.Len(), 0)
+}
+
+func TestShardsLimits(t *testing.T) {
+	c := NewCache[string, int](WithShards(3), WithMaxEntries(10))
+	defer c.Close()
+	assert.Len(t, c.shards, 3)
+
+	sum := 0
+	for _, s := range c.shards {
+		sum += s.maxEntries
+	}
+	assert.Equal(t, sum, 10)
+
+	for i := 0; i < 1000; i++ {
+		_ = c.Set(strconv.Itoa(i), i, 0)
+		assert.True(t, c.Len() <= 10)
+	}
+
+	c = NewCache[string, int](WithShards(3), WithMaxBytes(100), WithSizer(func(key string, val interface{}) int64 {
+		return 7
+	}))
+	defer c.Close()
+
+	var bytes int64
+	for _, s := range c.shards {
+		bytes += s.maxBytes
+	}
+	assert.Equal(t, bytes, int64(100))
+
+	for i := 0; i < 1000; i++ {
+		_ = c.Set(strconv.Itoa(i), i, 0)
+		assert.True(t, c.Bytes() <= 100)
+	}
+
+	// shards are reduced to the limits
+	c = NewCache[string, int](WithShards(8), WithMaxEntries(3))
+	defer c.Close()
+	assert.Len(t, c.shards, 3)
+
+	for i := 0; i < 100; i++ {
+		_ = c.Set(strconv.Itoa(i), i, 0)
+	}
+	assert.Equal(t, c.Len(), 3)
+}
+
+func TestShardsGC(t *testing.T) {
+	c := NewCache[int, int](WithShards(4))
+	defer c.Close()
+
+	c.SetGC(1, 5)
+
+	for i := 0; i < 20; i++ {
+		_ = c.Set(i, i, 100*time.Millisecond)
+	}
+
+	// gc is incremental, at most 5 keys are removed once
+	time.Sleep(1100 * time.Millisecond)
+	assert.Equal(t, c.Len(), 15)
+	assert.Equal(t, c.Expired(), uint64(5))
+}
+
+// benchCache is cache methods under benchmark
+type benchCache interface {
+	Set(key string, val interface{}, ttl int64) error
+	Get(key string) interface{}
+	Incr(key string) error
+}
+
+// mutexObjects is cache guarded by a single RWMutex as before sharding, the baseline of benchmarks
+type mutexObjects struct {
+	values map[string]*mutexObject
+	sync.RWMutex
+}
+
+// mutexObject is value of mutexObjects
+type mutexObject struct {
+	value  interface{}
+	expire int64
+}
+
+// Set set key value to cache
+func (o *mutexObjects) Set(key string, val interface{}, ttl int64) error {
+	if ttl > 0 {
+		ttl = time.Now().Add(time.Duration(ttl) * time.Second).Unix()
+	}
+
+	o.Lock()
+	defer o.Unlock()
+	o.values[key] = &mutexObject{val, ttl}
+
+	return nil
+}
+
+// Get get value from cache
+func (o *mutexObjects) Get(key string) interface{} {
+	o.RLock()
+	defer o.RUnlock()
+
+	v, ok := o.values[key]
+	if !ok || v.expire > 0 && v.expire <= time.Now().Unix() {
+		return nil
+	}
+
+	return v.value
+}
+
+// Incr increase cache counter
+func (o *mutexObjects) Incr(key string) error {
+	o.Lock()
+	defer o.Unlock()
+
+	v, ok := o.values[key]
+	if !ok {
+		return ErrKeyNotExists
+	}
+
+	n, _, err := base.AddInt(v.value, 1)
+	if err != nil {
+		return err
+	}
+	v.value = n
+
+	return nil
+}
+
+func benchmarkCache(b *testing.B, c benchCache, writePercent int, incr bool) {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+		_ = c.Set(keys[i], i, 0)
+	}
+
+	var seed int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		// every goroutine starts from a different key
+		i := int(atomic.AddInt64(&seed, 1)) * 7919
+		for pb.Next() {
+			k := keys[i%len(keys)]
+			switch {
+			case i%100 >= writePercent:
+				_ = c.Get(k)
+			case incr:
+				_ = c.Incr(k)
+			default:
+				_ = c.Set(k, i, 0)
+			}
+			i++
+		}
+	})
+}
+
+// benchmarkShards runs benchmark on the baseline and caches of shards
+func benchmarkShards(b *testing.B, writePercent int, incr bool) {
+	b.Run("baseline", func(b *testing.B) {
+		benchmarkCache(b, &mutexObjects{values: map[string]*mutexObject{}}, writePercent, incr)
+	})
+
+	for _, n := range []int{1, 16, 64} {
+		b.Run(fmt.Sprintf("shards=%d", n), func(b *testing.B) {
+			c := New(WithShards(n))
+			defer c.Close()
+			benchmarkCache(b, c, writePercent, incr)
+		})
+	}
+}
+
+func BenchmarkReadHeavy(b *testing.B) {
+	benchmarkShards(b, 10, false)
+}
+
+func BenchmarkWriteHeavy(b *testing.B) {
+	benchmarkShards(b, 90, false)
+}
+
+func BenchmarkIncrHeavy(b *testing.B) {
+	benchmarkShards(b, 90, true)
+}
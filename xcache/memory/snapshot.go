@@ -0,0 +1,190 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package memory
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// record is storing single key in snapshot
+type record struct {
+	Key    string
+	Value  []byte
+	Expire int64
+	Tags   []string
+}
+
+// holder is wrapping value for gob encoding of interface
+type holder struct {
+	V interface{}
+}
+
+// Save write all unexpired keys to w with expire time, value of custom type must be
+// registered by gob.Register, keys that can not be encoded are skipped and returned
+func (o *Objects) Save(w io.Writer) (map[string]error, error) {
+	skipped := map[string]error{}
+	enc := gob.NewEncoder(w)
+
+	var err error
+	o.cache.each(func(v *entry[string, interface{}]) bool {
+		buf := &bytes.Buffer{}
+		if e := gob.NewEncoder(buf).Encode(holder{v.value}); e != nil {
+			skipped[v.key] = e
+			return true
+		}
+		err = enc.Encode(record{v.key, buf.Bytes(), v.expire, v.tags})
+		return err == nil
+	})
+
+	return skipped, err
+}
+
+// Load read keys from r which is written by Save, keys expired are dropped
+func (o *Objects) Load(r io.Reader) error {
+	dec := gob.NewDecoder(r)
+	for {
+		var v record
+		err := dec.Decode(&v)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		ttl := time.Duration(0)
+		if v.Expire > 0 {
+			ttl = time.Until(time.Unix(0, v.Expire))
+			if ttl <= 0 {
+				continue
+			}
+		}
+
+		var h holder
+		err = gob.NewDecoder(bytes.NewReader(v.Value)).Decode(&h)
+		if err != nil {
+			return err
+		}
+
+		// value larger than the cache is skipped
+		err = o.cache.SetWithTags(v.Key, h.V, ttl, v.Tags...)
+		if err != nil && !errors.Is(err, ErrValueTooLarge) {
+			return err
+		}
+	}
+}
+
+// SaveFile save keys to file, it is written to a temp file and renamed
+func (o *Objects) SaveFile(fpath string) (map[string]error, error) {
+	fd, err := os.CreateTemp(filepath.Dir(fpath), ".xcache-*")
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.Remove(fd.Name())
+
+	skipped, err := o.Save(fd)
+	if err == nil {
+		err = fd.Sync()
+	}
+
+	if e := fd.Close(); err == nil {
+		err = e
+	}
+
+	if err != nil {
+		return skipped, err
+	}
+
+	return skipped, os.Rename(fd.Name(), fpath)
+}
+
+// LoadFile load keys from file which is written by SaveFile
+func (o *Objects) LoadFile(fpath string) error {
+	fd, err := os.Open(fpath)
+	if err != nil {
+		return err
+	}
+
+	defer fd.Close()
+
+	return o.Load(fd)
+}
+
+// SetSnapshot save keys to file every interval seconds and on Close,
+// f is called with the result of every save if not nil, interval <= 0 means disabled
+func (o *Objects) SetSnapshot(fpath string, interval int, f func(skipped map[string]error, err error)) {
+	o.stopSnapshot()
+
+	if interval <= 0 {
+		return
+	}
+
+	o.snapLock.Lock()
+	defer o.snapLock.Unlock()
+
+	o.snapExit = make(chan bool)
+	o.snapDone = make(chan bool)
+	go o.snapshot(fpath, time.Duration(interval)*time.Second, f, o.snapExit, o.snapDone)
+}
+
+// stopSnapshot stop the snapshot service and wait for the final save
+func (o *Objects) stopSnapshot() {
+	o.snapLock.Lock()
+	defer o.snapLock.Unlock()
+
+	if o.snapExit != nil {
+		close(o.snapExit)
+		<-o.snapDone
+		o.snapExit = nil
+	}
+}
+
+// snapshot do snapshot every interval
+func (o *Objects) snapshot(fpath string, interval time.Duration,
+	f func(map[string]error, error), exit, done chan bool) {
+	defer close(done)
+
+	save := func() {
+		skipped, err := o.SaveFile(fpath)
+		if f != nil {
+			f(skipped, err)
+		}
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-exit:
+			save()
+			return
+		case <-t.C:
+			save()
+		}
+	}
+}
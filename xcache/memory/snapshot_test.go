@@ -0,0 +1,155 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package memory
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/likexian/gokit/assert"
+)
+
+func TestSaveLoad(t *testing.T) {
+	c := New()
+	defer c.Close()
+
+	type custom struct{ V int }
+
+	_ = c.Set("a", 1, 0)
+	_ = c.Set("b", "b", 60)
+	_ = c.Set("c", []string{"c"}, 1)
+	_ = c.Set("d", custom{1}, 0)
+	_ = c.Set("e", 1, 1)
+	time.Sleep(1 * time.Second)
+
+	buf := &bytes.Buffer{}
+	skipped, err := c.Save(buf)
+	assert.Nil(t, err)
+	assert.Len(t, skipped, 1)
+	assert.NotNil(t, skipped["d"])
+
+	n := New()
+	defer n.Close()
+
+	err = n.Load(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, n.Len(), 2)
+	assert.Equal(t, n.Get("a"), 1)
+	assert.Equal(t, n.Get("b"), "b")
+
+	// remaining ttl is kept
+	n.cache.each(func(v *entry[string, interface{}]) bool {
+		if v.key == "b" {
+			assert.Gt(t, v.expire, time.Now().Add(50*time.Second).UnixNano())
+		}
+		return true
+	})
+
+	err = n.Load(bytes.NewBufferString("x"))
+	assert.NotNil(t, err)
+}
+
+func TestLoadExpired(t *testing.T) {
+	c := New()
+	defer c.Close()
+
+	_ = c.Set("a", 1, 0)
+	_ = c.Set("b", 1, 1)
+
+	buf := &bytes.Buffer{}
+	_, err := c.Save(buf)
+	assert.Nil(t, err)
+
+	// ttl runs out while the snapshot sits on disk
+	time.Sleep(1 * time.Second)
+
+	n := New()
+	defer n.Close()
+
+	err = n.Load(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, n.Len(), 1)
+	assert.True(t, n.Has("a"))
+}
+
+func TestSnapshot(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "cache.snap")
+
+	c := New()
+
+	saved := make(chan error, 10)
+	c.SetSnapshot(fpath, 1, func(skipped map[string]error, err error) {
+		saved <- err
+	})
+
+	_ = c.Set("a", 1, 0)
+	assert.Nil(t, <-saved)
+
+	n := New()
+	err := n.LoadFile(fpath)
+	assert.Nil(t, err)
+	assert.Equal(t, n.Get("a"), 1)
+	n.Close()
+
+	// keys are saved on close
+	_ = c.Set("b", 2, 0)
+	c.Close()
+	assert.Nil(t, <-saved)
+
+	n = New()
+	defer n.Close()
+	err = n.LoadFile(fpath)
+	assert.Nil(t, err)
+	assert.Equal(t, n.Get("b"), 2)
+
+	err = n.LoadFile(fpath + ".x")
+	assert.NotNil(t, err)
+
+	_, err = n.SaveFile(filepath.Join(fpath, "x", "x"))
+	assert.NotNil(t, err)
+
+	// disable snapshot
+	n.SetSnapshot(fpath, 1, nil)
+	n.SetSnapshot(fpath, 0, nil)
+}
+
+func TestSaveLoadTags(t *testing.T) {
+	c := New()
+	defer c.Close()
+
+	_ = c.SetWithTags("a", 1, 0, "t")
+	_ = c.Set("b", 1, 0)
+
+	buf := &bytes.Buffer{}
+	_, err := c.Save(buf)
+	assert.Nil(t, err)
+
+	n := New()
+	defer n.Close()
+
+	err = n.Load(buf)
+	assert.Nil(t, err)
+
+	_ = n.InvalidateTag("t")
+	assert.False(t, n.Has("a"))
+	assert.True(t, n.Has("b"))
+}
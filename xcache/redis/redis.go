@@ -0,0 +1,730 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package redis
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/likexian/gokit/xcache/base"
+)
+
+var (
+	// ErrClosed is client closed error
+	ErrClosed = errors.New("xcache: redis client is closed")
+	// ErrPoolTimeout is pool timeout error
+	ErrPoolTimeout = errors.New("xcache: redis connection pool timeout")
+	// ErrKeyNotExists is key not exists error
+	ErrKeyNotExists = base.ErrKeyNotExists
+	// ErrDataTypeNotSupported is data type not supported error
+	ErrDataTypeNotSupported = base.ErrDataTypeNotSupported
+)
+
+const (
+	// tagPrefix is key prefix of the set storing keys of a tag
+	tagPrefix = "xcache:tag:"
+	// keyTagPrefix is key prefix of the set storing tags of a key, it lives as long as the key
+	keyTagPrefix = "xcache:keytag:"
+)
+
+// Options is redis client options
+type Options struct {
+	// Addr is server address, default is 127.0.0.1:6379
+	Addr string
+	// Password is server password, empty for no auth
+	Password string
+	// DB is database index to select
+	DB int
+	// PoolSize is max number of connections, default is 10
+	PoolSize int
+	// PoolTimeout is max time to wait for a free connection, default is 5s
+	PoolTimeout time.Duration
+	// DialTimeout is timeout of connecting, default is 5s
+	DialTimeout time.Duration
+	// ReadTimeout is timeout of reading reply, default is 3s
+	ReadTimeout time.Duration
+	// WriteTimeout is timeout of writing command, default is 3s
+	WriteTimeout time.Duration
+}
+
+// Client is redis client, it speaks RESP and implements Cachex
+type Client struct {
+	opts    Options
+	idle    chan *conn
+	sem     chan struct{}
+	closed  bool
+	stats   base.Recorder
+	onEvict atomic.Pointer[base.EvictFunc]
+	sync.RWMutex
+}
+
+// conn is a pooled connection
+type conn struct {
+	nc net.Conn
+	r  *bufio.Reader
+	w  *bufio.Writer
+}
+
+// Version returns package version
+func Version() string {
+	return "0.1.0"
+}
+
+// Author returns package author
+func Author() string {
+	return "[Li Kexian](https://www.likexian.com/)"
+}
+
+// License returns package license
+func License() string {
+	return "Licensed under the Apache License 2.0"
+}
+
+// New returns a new redis client, connections are made on demand
+func New(opts Options) *Client {
+	if opts.Addr == "" {
+		opts.Addr = "127.0.0.1:6379"
+	}
+
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+
+	if opts.PoolTimeout <= 0 {
+		opts.PoolTimeout = 5 * time.Second
+	}
+
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 5 * time.Second
+	}
+
+	if opts.ReadTimeout <= 0 {
+		opts.ReadTimeout = 3 * time.Second
+	}
+
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = 3 * time.Second
+	}
+
+	return &Client{
+		opts: opts,
+		idle: make(chan *conn, opts.PoolSize),
+		sem:  make(chan struct{}, opts.PoolSize),
+	}
+}
+
+// Do send command to server and returns the reply,
+// reply is string, int64, nil, []interface{}, or error if server replied error
+func (c *Client) Do(args ...string) (interface{}, error) {
+	vs, err := c.send(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if e, ok := vs[0].(ReplyError); ok {
+		return nil, e
+	}
+
+	return vs[0], nil
+}
+
+// Set set key value to cache, value is stored as string, ttl is in seconds
+func (c *Client) Set(key string, val interface{}, ttl int64) error {
+	return c.SetWithTTL(key, val, time.Duration(ttl)*time.Second)
+}
+
+// SetWithTTL set key value to cache, value is stored as string, ttl <= 0 means never expire,
+// tags set before are removed from the key
+func (c *Client) SetWithTTL(key string, val interface{}, ttl time.Duration) error {
+	return c.SetWithTags(key, val, ttl)
+}
+
+// SetNX set key value to cache only if key is not exists, returns true if set
+func (c *Client) SetNX(key string, val interface{}, ttl time.Duration) (bool, error) {
+	v, err := toString(val)
+	if err != nil {
+		return false, err
+	}
+
+	r, err := c.Do(withTTL([]string{"SET", key, v, "NX"}, ttl)...)
+	if err != nil || r == nil {
+		return false, err
+	}
+
+	c.stats.Set()
+
+	return true, nil
+}
+
+// Get get value from cache, value is returned as string
+func (c *Client) Get(key string) interface{} {
+	v, err := c.Do("GET", key)
+	c.stats.Hit(err == nil && v != nil)
+	if err != nil {
+		return nil
+	}
+
+	return v
+}
+
+// MGet get multiple value from cache
+func (c *Client) MGet(key ...string) []interface{} {
+	r := make([]interface{}, len(key))
+	if len(key) == 0 {
+		return r
+	}
+
+	v, err := c.Do(append([]string{"MGET"}, key...)...)
+	if err != nil {
+		return r
+	}
+
+	if vs, ok := v.([]interface{}); ok && len(vs) == len(key) {
+		for _, v := range vs {
+			c.stats.Hit(v != nil)
+		}
+		return vs
+	}
+
+	return r
+}
+
+// Has returns key is exists
+func (c *Client) Has(key string) bool {
+	v, err := c.Do("EXISTS", key)
+	if err != nil {
+		return false
+	}
+
+	return v == int64(1)
+}
+
+// Del remove key from cache, it is done by GETDEL if OnEvict is set, which requires redis 6.2 or later
+func (c *Client) Del(key string) error {
+	c.stats.Delete()
+
+	f := c.onEvict.Load()
+	if f == nil {
+		_, err := c.Do("DEL", key, keyTagPrefix+key)
+		return err
+	}
+
+	vs, err := c.multi([]string{"GETDEL", key}, []string{"DEL", keyTagPrefix + key})
+	if err != nil {
+		return err
+	}
+
+	if vs[0] != nil {
+		(*f)(key, vs[0], base.Deleted)
+	}
+
+	return nil
+}
+
+// Incr increase cache counter, not exists key is set to 0 before increase
+func (c *Client) Incr(key string) error {
+	_, err := c.Do("INCR", key)
+	return err
+}
+
+// Decr decrease cache counter, not exists key is set to 0 before decrease
+func (c *Client) Decr(key string) error {
+	_, err := c.Do("DECR", key)
+	return err
+}
+
+// IncrBy add delta to cache counter and returns the new value,
+// not exists key is set to 0 before increase
+func (c *Client) IncrBy(key string, delta int64) (int64, error) {
+	v, err := c.Do("INCRBY", key, strconv.FormatInt(delta, 10))
+	if err != nil {
+		return 0, err
+	}
+
+	n, ok := v.(int64)
+	if !ok {
+		return 0, ErrProtocol
+	}
+
+	return n, nil
+}
+
+// IncrByFloat add delta to float cache counter and returns the new value,
+// not exists key is set to 0 before increase
+func (c *Client) IncrByFloat(key string, delta float64) (float64, error) {
+	v, err := c.Do("INCRBYFLOAT", key, strconv.FormatFloat(delta, 'f', -1, 64))
+	if err != nil {
+		return 0, err
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return 0, ErrProtocol
+	}
+
+	return strconv.ParseFloat(s, 64)
+}
+
+// TTL returns remaining ttl of key, NoExpire if key never expire
+func (c *Client) TTL(key string) (time.Duration, error) {
+	v, err := c.Do("PTTL", key)
+	if err != nil {
+		return 0, err
+	}
+
+	switch v {
+	case int64(-2):
+		return 0, ErrKeyNotExists
+	case int64(-1):
+		return base.NoExpire, nil
+	}
+
+	n, ok := v.(int64)
+	if !ok {
+		return 0, ErrProtocol
+	}
+
+	return time.Duration(n) * time.Millisecond, nil
+}
+
+// Expire set ttl of key, key is expired at once if ttl <= 0, ttl is rounded up to millisecond
+func (c *Client) Expire(key string, ttl time.Duration) error {
+	ms := strconv.FormatInt(millis(ttl), 10)
+	vs, err := c.multi([]string{"PEXPIRE", key, ms}, []string{"PEXPIRE", keyTagPrefix + key, ms})
+	if err != nil {
+		return err
+	}
+
+	if vs[0] != int64(1) {
+		return ErrKeyNotExists
+	}
+
+	return nil
+}
+
+// Persist remove ttl of key, so it never expire
+func (c *Client) Persist(key string) error {
+	vs, err := c.multi([]string{"PERSIST", key}, []string{"PERSIST", keyTagPrefix + key})
+	if err != nil {
+		return err
+	}
+
+	if vs[0] == int64(1) {
+		return nil
+	}
+
+	// PERSIST replies 0 for both not exists key and key without ttl
+	if !c.Has(key) {
+		return ErrKeyNotExists
+	}
+
+	return nil
+}
+
+// Keys returns sorted unexpired keys matched by the glob pattern, it is O(N) on the server,
+// sets of tags are not included
+func (c *Client) Keys(pattern string) ([]string, error) {
+	v, err := c.Do("KEYS", pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	vs, ok := v.([]interface{})
+	if !ok {
+		return nil, ErrProtocol
+	}
+
+	r := make([]string, 0, len(vs))
+	for _, v := range vs {
+		if s, ok := v.(string); ok && !strings.HasPrefix(s, tagPrefix) && !strings.HasPrefix(s, keyTagPrefix) {
+			r = append(r, s)
+		}
+	}
+
+	sort.Strings(r)
+
+	return r, nil
+}
+
+// SetWithTags set key value to cache with tags, keys of a tag are stored in a set
+// named by tag with prefix xcache:tag:, the set lives as long as its longest key,
+// tags of a key are stored in a set named by key with prefix xcache:keytag:,
+// they are written in a MULTI transaction
+func (c *Client) SetWithTags(key string, val interface{}, ttl time.Duration, tags ...string) error {
+	v, err := toString(val)
+	if err != nil {
+		return err
+	}
+
+	cmds := [][]string{withTTL([]string{"SET", key, v}, ttl), {"DEL", keyTagPrefix + key}}
+	if len(tags) > 0 {
+		cmds = append(cmds, append([]string{"SADD", keyTagPrefix + key}, tags...))
+		if ttl > 0 {
+			cmds = append(cmds, []string{"PEXPIRE", keyTagPrefix + key, strconv.FormatInt(millis(ttl), 10)})
+		}
+	}
+
+	for _, t := range tags {
+		cs, err := c.tag(t, key, ttl)
+		if err != nil {
+			return err
+		}
+		cmds = append(cmds, cs...)
+	}
+
+	c.stats.Set()
+	_, err = c.multi(cmds...)
+
+	return err
+}
+
+// InvalidateTag remove all keys carrying the tag from cache,
+// keys set again without the tag are kept
+func (c *Client) InvalidateTag(tag string) error {
+	v, err := c.Do("SMEMBERS", tagPrefix+tag)
+	if err != nil {
+		return err
+	}
+
+	vs, ok := v.([]interface{})
+	if !ok {
+		return ErrProtocol
+	}
+
+	ks := make([]string, 0, len(vs))
+	cmds := make([][]string, 0, len(vs))
+	for _, v := range vs {
+		if k, ok := v.(string); ok {
+			ks = append(ks, k)
+			cmds = append(cmds, []string{"SISMEMBER", keyTagPrefix + k, tag})
+		}
+	}
+
+	// set of tag may keep keys set again without the tag
+	rs, err := c.send(cmds...)
+	if err != nil {
+		return err
+	}
+
+	dels := []string{"DEL", tagPrefix + tag}
+	for i, k := range ks {
+		if rs[i] != int64(1) {
+			continue
+		}
+		if c.onEvict.Load() != nil {
+			err = c.Del(k)
+			if err != nil {
+				return err
+			}
+		} else {
+			c.stats.Delete()
+			dels = append(dels, k, keyTagPrefix+k)
+		}
+	}
+
+	_, err = c.Do(dels...)
+
+	return err
+}
+
+// Namespace returns a view of cache with keys and tags prefixed by name,
+// it can be flushed without touching keys out of the namespace
+func (c *Client) Namespace(name string) base.Cachex {
+	return base.NewNamespace(c, name)
+}
+
+// SetGC do nothing, the server expires keys itself
+func (c *Client) SetGC(gcInterval, gcMaxOnce int) {}
+
+// Flush empty the selected database
+func (c *Client) Flush() error {
+	_, err := c.Do("FLUSHDB")
+	return err
+}
+
+// OnEvict set the func called after a key is deleted by this client,
+// keys expired or flushed are removed by the server and are not reported,
+// Del gets the value by GETDEL once it is set, which requires redis 6.2 or later
+func (c *Client) OnEvict(f base.EvictFunc) {
+	if f == nil {
+		c.onEvict.Store(nil)
+	} else {
+		c.onEvict.Store(&f)
+	}
+}
+
+// Stats returns cache statistics of this client, keys is number of keys in the selected database
+func (c *Client) Stats() base.Stats {
+	r := c.stats.Stats()
+	if v, err := c.Do("DBSIZE"); err == nil {
+		if n, ok := v.(int64); ok {
+			r.Keys = int(n)
+		}
+	}
+
+	return r
+}
+
+// Close close all the connections
+func (c *Client) Close() error {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.closed {
+		return nil
+	}
+
+	c.closed = true
+	for {
+		select {
+		case cn := <-c.idle:
+			cn.nc.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// tag returns commands adding key to set of tag, the set lives as long as its longest key
+func (c *Client) tag(tag, key string, ttl time.Duration) ([][]string, error) {
+	cmds := [][]string{{"SADD", tagPrefix + tag, key}}
+	if ttl <= 0 {
+		return append(cmds, []string{"PERSIST", tagPrefix + tag}), nil
+	}
+
+	v, err := c.Do("PTTL", tagPrefix+tag)
+	if err != nil {
+		return nil, err
+	}
+
+	// -1 is never expire, -2 is not exists before
+	n, ok := v.(int64)
+	if ok && (n == -1 || n > millis(ttl)) {
+		return cmds, nil
+	}
+
+	return append(cmds, []string{"PEXPIRE", tagPrefix + tag, strconv.FormatInt(millis(ttl)+1, 10)}), nil
+}
+
+// send send commands on one connection without waiting for replies between them,
+// returns replies of the commands, error replied by server is returned as reply
+func (c *Client) send(cmds ...[]string) ([]interface{}, error) {
+	cn, err := c.get()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cn.nc.SetWriteDeadline(time.Now().Add(c.opts.WriteTimeout))
+	for i := 0; err == nil && i < len(cmds); i++ {
+		err = writeCommand(cn.w, cmds[i]...)
+	}
+
+	if err != nil {
+		c.put(cn, true)
+		return nil, err
+	}
+
+	err = cn.nc.SetReadDeadline(time.Now().Add(c.opts.ReadTimeout))
+	if err != nil {
+		c.put(cn, true)
+		return nil, err
+	}
+
+	vs := make([]interface{}, len(cmds))
+	for i := range vs {
+		vs[i], err = readReply(cn.r)
+		if err != nil {
+			c.put(cn, true)
+			return nil, err
+		}
+	}
+
+	c.put(cn, false)
+
+	return vs, nil
+}
+
+// multi send commands in a MULTI/EXEC transaction, so they are executed at once,
+// returns replies of the commands, or the first error replied by server
+func (c *Client) multi(cmds ...[]string) ([]interface{}, error) {
+	vs, err := c.send(append(append([][]string{{"MULTI"}}, cmds...), []string{"EXEC"})...)
+	if err != nil {
+		return nil, err
+	}
+
+	// error of queuing is replied before EXEC
+	for _, v := range vs {
+		if e, ok := v.(ReplyError); ok {
+			return nil, e
+		}
+	}
+
+	rs, ok := vs[len(vs)-1].([]interface{})
+	if !ok || len(rs) != len(cmds) {
+		return nil, ErrProtocol
+	}
+
+	for _, v := range rs {
+		if e, ok := v.(ReplyError); ok {
+			return nil, e
+		}
+	}
+
+	return rs, nil
+}
+
+// get returns an idle connection or dial a new one
+func (c *Client) get() (*conn, error) {
+	t := time.NewTimer(c.opts.PoolTimeout)
+	defer t.Stop()
+
+	select {
+	case c.sem <- struct{}{}:
+	case <-t.C:
+		return nil, ErrPoolTimeout
+	}
+
+	c.RLock()
+	closed := c.closed
+	c.RUnlock()
+	if closed {
+		<-c.sem
+		return nil, ErrClosed
+	}
+
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+	}
+
+	cn, err := c.dial()
+	if err != nil {
+		<-c.sem
+		return nil, err
+	}
+
+	return cn, nil
+}
+
+// put returns connection to the pool, the broken one is closed
+func (c *Client) put(cn *conn, broken bool) {
+	defer func() { <-c.sem }()
+
+	c.RLock()
+	defer c.RUnlock()
+
+	if broken || c.closed {
+		cn.nc.Close()
+		return
+	}
+
+	select {
+	case c.idle <- cn:
+	default:
+		cn.nc.Close()
+	}
+}
+
+// dial connect to server, do auth and select database
+func (c *Client) dial() (*conn, error) {
+	nc, err := net.DialTimeout("tcp", c.opts.Addr, c.opts.DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	cn := &conn{nc: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+
+	init := [][]string{}
+	if c.opts.Password != "" {
+		init = append(init, []string{"AUTH", c.opts.Password})
+	}
+
+	if c.opts.DB > 0 {
+		init = append(init, []string{"SELECT", strconv.Itoa(c.opts.DB)})
+	}
+
+	for _, args := range init {
+		err = cn.nc.SetDeadline(time.Now().Add(c.opts.DialTimeout))
+		if err == nil {
+			err = writeCommand(cn.w, args...)
+		}
+		var v interface{}
+		if err == nil {
+			v, err = readReply(cn.r)
+		}
+		if err == nil {
+			if e, ok := v.(ReplyError); ok {
+				err = e
+			}
+		}
+		if err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+
+	return cn, nil
+}
+
+// withTTL returns command args with PX option appended if ttl > 0,
+// ttl is rounded up to millisecond
+func withTTL(args []string, ttl time.Duration) []string {
+	if ttl <= 0 {
+		return args
+	}
+
+	return append(args, "PX", strconv.FormatInt(millis(ttl), 10))
+}
+
+// millis returns ttl in milliseconds, positive ttl is rounded up, so it is never expired at once
+func millis(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return ttl.Milliseconds()
+	}
+
+	return int64((ttl + time.Millisecond - 1) / time.Millisecond)
+}
+
+// toString returns value as string for storing
+func toString(val interface{}) (string, error) {
+	switch v := val.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, bool:
+		return fmt.Sprint(v), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", ErrDataTypeNotSupported
+	}
+}
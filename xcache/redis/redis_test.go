@@ -0,0 +1,410 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/likexian/gokit/assert"
+	"github.com/likexian/gokit/xcache/base"
+)
+
+func TestVersion(t *testing.T) {
+	assert.Contains(t, Version(), ".")
+	assert.Contains(t, Author(), "likexian")
+	assert.Contains(t, License(), "Apache License")
+}
+
+func TestBase(t *testing.T) {
+	s, err := NewServer("127.0.0.1:0")
+	assert.Nil(t, err)
+	defer s.Close()
+
+	c := New(Options{Addr: s.Addr()})
+	defer c.Close()
+
+	// has
+	nx := c.Has("x")
+	assert.False(t, nx)
+
+	// set
+	err = c.Set("x", 1, -1)
+	assert.Nil(t, err)
+
+	// check set
+	nx = c.Has("x")
+	assert.True(t, nx)
+
+	// get
+	v := c.Get("x")
+	assert.Equal(t, v, "1")
+
+	// del
+	err = c.Del("x")
+	assert.Nil(t, err)
+
+	// check del
+	nx = c.Has("x")
+	assert.False(t, nx)
+	v = c.Get("x")
+	assert.Equal(t, v, nil)
+
+	for i := 0; i < 10; i++ {
+		k := fmt.Sprintf("%d", i)
+		err = c.Set(k, i, 0)
+		assert.Nil(t, err)
+		assert.True(t, c.Has(k))
+	}
+
+	// get multiple key
+	vs := c.MGet("1", "2", "x")
+	assert.Equal(t, vs, []interface{}{"1", "2", nil})
+
+	// flush cache
+	err = c.Flush()
+	assert.Nil(t, err)
+	v = c.Get("1")
+	assert.Equal(t, v, nil)
+
+	// get on expired key
+	err = c.Set("xx", 1, 1)
+	assert.Nil(t, err)
+	assert.True(t, c.Has("xx"))
+	time.Sleep(1 * time.Second)
+	v = c.Get("xx")
+	assert.Equal(t, v, nil)
+
+	// value types
+	for _, v := range []interface{}{"s", []byte("s"), true, uint8(1), 1.5, float32(1.5)} {
+		err = c.Set("v", v, 0)
+		assert.Nil(t, err)
+	}
+	err = c.Set("v", struct{}{}, 0)
+	assert.Equal(t, err, ErrDataTypeNotSupported)
+
+	// unknown command
+	_, err = c.Do("NOTEXISTS")
+	assert.NotNil(t, err)
+	c.SetGC(1, 1)
+}
+
+func TestIncr(t *testing.T) {
+	s, err := NewServer("127.0.0.1:0")
+	assert.Nil(t, err)
+	defer s.Close()
+
+	c := New(Options{Addr: s.Addr(), PoolSize: 2})
+	defer c.Close()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				assert.Nil(t, c.Incr("n"))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, c.Get("n"), "100")
+
+	err = c.Decr("n")
+	assert.Nil(t, err)
+	assert.Equal(t, c.Get("n"), "99")
+
+	err = c.Set("x", "o", 0)
+	assert.Nil(t, err)
+	err = c.Incr("x")
+	assert.NotNil(t, err)
+	err = c.Decr("x")
+	assert.NotNil(t, err)
+}
+
+func TestAuthSelect(t *testing.T) {
+	s, err := NewServer("127.0.0.1:0")
+	assert.Nil(t, err)
+	defer s.Close()
+
+	s.RequirePass("secret")
+
+	c := New(Options{Addr: s.Addr()})
+	err = c.Set("x", 1, 0)
+	assert.NotNil(t, err)
+	c.Close()
+
+	c = New(Options{Addr: s.Addr(), Password: "wrong"})
+	err = c.Set("x", 1, 0)
+	assert.NotNil(t, err)
+	c.Close()
+
+	c0 := New(Options{Addr: s.Addr(), Password: "secret"})
+	defer c0.Close()
+	c1 := New(Options{Addr: s.Addr(), Password: "secret", DB: 1})
+	defer c1.Close()
+
+	err = c0.Set("x", 0, 0)
+	assert.Nil(t, err)
+	err = c1.Set("x", 1, 0)
+	assert.Nil(t, err)
+
+	// databases are separated
+	err = c1.Flush()
+	assert.Nil(t, err)
+	assert.Equal(t, c0.Get("x"), "0")
+	assert.Equal(t, c1.Get("x"), nil)
+}
+
+func TestTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+
+	// server accepts but never replies
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _ = io.Copy(io.Discard, c)
+				c.Close()
+			}()
+		}
+	}()
+
+	c := New(Options{Addr: l.Addr().String(), PoolSize: 1, ReadTimeout: 100 * time.Millisecond})
+	defer c.Close()
+
+	_, err = c.Do("PING")
+	assert.NotNil(t, err)
+
+	// broken connection is released
+	_, err = c.Do("PING")
+	assert.NotNil(t, err)
+
+	c = New(Options{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond})
+	_, err = c.Do("PING")
+	assert.NotNil(t, err)
+}
+
+func TestClose(t *testing.T) {
+	s, err := NewServer("127.0.0.1:0")
+	assert.Nil(t, err)
+	defer s.Close()
+
+	c := New(Options{Addr: s.Addr()})
+	v, err := c.Do("PING")
+	assert.Nil(t, err)
+	assert.Equal(t, v, "PONG")
+
+	err = c.Close()
+	assert.Nil(t, err)
+	err = c.Close()
+	assert.Nil(t, err)
+
+	_, err = c.Do("PING")
+	assert.Equal(t, err, ErrClosed)
+}
+
+func TestOnEvict(t *testing.T) {
+	s, err := NewServer("127.0.0.1:0")
+	assert.Nil(t, err)
+	defer s.Close()
+
+	c := New(Options{Addr: s.Addr()})
+	defer c.Close()
+
+	evicted := map[string]interface{}{}
+	c.OnEvict(func(key string, val interface{}, reason base.EvictReason) {
+		assert.Equal(t, reason, base.Deleted)
+		evicted[key] = val
+	})
+
+	_ = c.Set("a", 1, 0)
+	_ = c.Set("b", 2, 0)
+	_ = c.Del("a")
+	_ = c.Del("x")
+	assert.Equal(t, evicted, map[string]interface{}{"a": "1"})
+
+	c.OnEvict(nil)
+	_ = c.Del("b")
+	assert.Len(t, evicted, 1)
+
+	_ = c.Set("a", 1, 0)
+	_ = c.Get("a")
+	_ = c.Get("x")
+	_ = c.MGet("a", "x")
+
+	st := c.Stats()
+	assert.Equal(t, st.Hits, uint64(2))
+	assert.Equal(t, st.Misses, uint64(2))
+	assert.Equal(t, st.Sets, uint64(3))
+	assert.Equal(t, st.Deletes, uint64(3))
+	assert.Equal(t, st.Keys, 1)
+}
+
+func TestTagTTL(t *testing.T) {
+	s, err := NewServer("127.0.0.1:0")
+	assert.Nil(t, err)
+	defer s.Close()
+
+	c := New(Options{Addr: s.Addr()})
+	defer c.Close()
+
+	err = c.SetWithTags("a", 1, time.Second, "t")
+	assert.Nil(t, err)
+	v, _ := c.Do("PTTL", tagPrefix+"t")
+	assert.True(t, v.(int64) > 0 && v.(int64) <= 1001)
+	v, _ = c.Do("PTTL", keyTagPrefix+"a")
+	assert.True(t, v.(int64) > 0 && v.(int64) <= 1000)
+
+	err = c.SetWithTags("b", 1, time.Minute, "t")
+	assert.Nil(t, err)
+	v, _ = c.Do("PTTL", tagPrefix+"t")
+	assert.True(t, v.(int64) > 1001)
+
+	err = c.SetWithTags("c", 1, 0, "t")
+	assert.Nil(t, err)
+	v, _ = c.Do("PTTL", tagPrefix+"t")
+	assert.Equal(t, v, int64(-1))
+
+	_, err = c.Do("GET", tagPrefix+"t")
+	assert.NotNil(t, err)
+
+	ks, err := c.Keys("*")
+	assert.Nil(t, err)
+	assert.Equal(t, ks, []string{"a", "b", "c"})
+}
+
+func TestPersistKeys(t *testing.T) {
+	s, err := NewServer("127.0.0.1:0")
+	assert.Nil(t, err)
+	defer s.Close()
+
+	c := New(Options{Addr: s.Addr()})
+	defer c.Close()
+
+	for _, k := range []string{"c", "a", "b"} {
+		err = c.SetWithTTL(k, 1, time.Minute)
+		assert.Nil(t, err)
+	}
+
+	ks, err := c.Keys("*")
+	assert.Nil(t, err)
+	assert.Equal(t, ks, []string{"a", "b", "c"})
+
+	err = c.Persist("a")
+	assert.Nil(t, err)
+	ttl, err := c.TTL("a")
+	assert.Nil(t, err)
+	assert.Equal(t, ttl, base.NoExpire)
+
+	// key without ttl
+	err = c.Persist("a")
+	assert.Nil(t, err)
+
+	err = c.Persist("x")
+	assert.Equal(t, err, ErrKeyNotExists)
+}
+
+func TestReadReply(t *testing.T) {
+	tests := []struct {
+		in  string
+		out interface{}
+		err error
+	}{
+		{"$3\r\nabc\r\n", "abc", nil},
+		{"$-1\r\n", nil, nil},
+		{"*2\r\n:1\r\n+OK\r\n", []interface{}{int64(1), "OK"}, nil},
+		{fmt.Sprintf("$%d\r\n", maxBulkLen+1), nil, ErrProtocol},
+		{fmt.Sprintf("*%d\r\n", maxBulkLen+1), nil, ErrProtocol},
+		{"*3\r\n:1\r\n", nil, io.EOF},
+	}
+
+	for _, v := range tests {
+		r, err := readReply(bufio.NewReader(strings.NewReader(v.in)))
+		assert.Equal(t, err, v.err, v.in)
+		assert.Equal(t, r, v.out, v.in)
+	}
+}
+
+func TestMillis(t *testing.T) {
+	tests := []struct {
+		in  time.Duration
+		out int64
+	}{
+		{-time.Second, -1000},
+		{0, 0},
+		{time.Nanosecond, 1},
+		{500 * time.Microsecond, 1},
+		{time.Millisecond, 1},
+		{time.Millisecond + time.Nanosecond, 2},
+		{time.Minute, 60000},
+	}
+
+	for _, v := range tests {
+		assert.Equal(t, millis(v.in), v.out, v)
+	}
+
+	assert.Equal(t, withTTL([]string{"SET", "x", "1"}, 0), []string{"SET", "x", "1"})
+	assert.Equal(t, withTTL([]string{"SET", "x", "1"}, time.Microsecond), []string{"SET", "x", "1", "PX", "1"})
+}
+
+func TestMulti(t *testing.T) {
+	s, err := NewServer("127.0.0.1:0")
+	assert.Nil(t, err)
+	defer s.Close()
+
+	c := New(Options{Addr: s.Addr()})
+	defer c.Close()
+
+	vs, err := c.multi([]string{"SET", "a", "1"}, []string{"INCR", "a"}, []string{"GET", "x"})
+	assert.Nil(t, err)
+	assert.Equal(t, vs, []interface{}{"OK", int64(2), nil})
+
+	// commands are executed even if one of them failed
+	_, err = c.multi([]string{"SET", "a", "x"}, []string{"INCR", "a"})
+	assert.NotNil(t, err)
+	assert.Equal(t, c.Get("a"), "x")
+
+	// unknown command discards the transaction
+	_, err = c.multi([]string{"SET", "a", "1"}, []string{"NOPE"})
+	assert.NotNil(t, err)
+	assert.Equal(t, c.Get("a"), "x")
+
+	vs, err = c.send([]string{"MULTI"}, []string{"SET", "b", "1"}, []string{"DISCARD"})
+	assert.Nil(t, err)
+	assert.Equal(t, vs, []interface{}{"OK", "QUEUED", "OK"})
+	assert.False(t, c.Has("b"))
+
+	_, err = c.Do("EXEC")
+	assert.NotNil(t, err)
+	_, err = c.send([]string{"MULTI"}, []string{"MULTI"}, []string{"DISCARD"})
+	assert.Nil(t, err)
+}
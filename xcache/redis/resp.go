@@ -0,0 +1,133 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package redis
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ReplyError is error replied by server
+type ReplyError string
+
+// ErrProtocol is protocol error
+var ErrProtocol = errors.New("xcache: redis protocol error")
+
+// maxBulkLen is max length of bulk string and array, the same as proto-max-bulk-len of redis
+const maxBulkLen = 512 << 20
+
+// Error returns error message
+func (e ReplyError) Error() string {
+	return string(e)
+}
+
+// writeCommand write command as array of bulk strings
+func writeCommand(w *bufio.Writer, args ...string) error {
+	_, err := fmt.Fprintf(w, "*%d\r\n", len(args))
+	if err != nil {
+		return err
+	}
+
+	for _, v := range args {
+		_, err = fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// readReply read one reply, returns string, int64, nil, []interface{} or ReplyError
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 {
+		return nil, ErrProtocol
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return ReplyError(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, ErrProtocol
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		if n > maxBulkLen {
+			return nil, ErrProtocol
+		}
+		buf := make([]byte, n+2)
+		_, err = io.ReadFull(r, buf)
+		if err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, ErrProtocol
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		if n > maxBulkLen {
+			return nil, ErrProtocol
+		}
+		vs := make([]interface{}, 0, min(n, 1024))
+		for i := 0; i < n; i++ {
+			v, err := readReply(r)
+			if err != nil {
+				return nil, err
+			}
+			vs = append(vs, v)
+		}
+		return vs, nil
+	default:
+		return nil, ErrProtocol
+	}
+}
+
+// readLine read one line without the trailing CRLF
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", ErrProtocol
+	}
+
+	return line[:len(line)-2], nil
+}
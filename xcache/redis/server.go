@@ -0,0 +1,772 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/likexian/gokit/xcache/base"
+)
+
+// Server is an in-process RESP server, it implements a small subset of redis commands,
+// and is intended to be used in tests without a real redis
+type Server struct {
+	listener net.Listener
+	password string
+	dbs      map[int]map[string]*item
+	conns    map[net.Conn]bool
+	wg       sync.WaitGroup
+	sync.Mutex
+}
+
+// item is storing value of a key
+type item struct {
+	value  string
+	set    map[string]struct{}
+	expire int64
+}
+
+// session is storing connection state
+type session struct {
+	db      int
+	authed  bool
+	multi   bool
+	aborted bool
+	queued  [][]string
+}
+
+// handler handles one command, the server lock is held
+type handler func(s *Server, ss *session, args []string) interface{}
+
+// statusReply is simple string reply
+type statusReply string
+
+// okReply is simple string reply OK
+const okReply = statusReply("OK")
+
+// handlers is supported commands
+var handlers = map[string]handler{
+	"PING":        cmdPing,
+	"SELECT":      cmdSelect,
+	"SET":         cmdSet,
+	"GET":         cmdGet,
+	"GETDEL":      cmdGetDel,
+	"MGET":        cmdMGet,
+	"EXISTS":      cmdExists,
+	"DEL":         cmdDel,
+	"INCR":        cmdIncr,
+	"DECR":        cmdDecr,
+	"INCRBY":      cmdIncrBy,
+	"DECRBY":      cmdDecrBy,
+	"INCRBYFLOAT": cmdIncrByFloat,
+	"PTTL":        cmdPTTL,
+	"PEXPIRE":     cmdPExpire,
+	"PERSIST":     cmdPersist,
+	"KEYS":        cmdKeys,
+	"SADD":        cmdSAdd,
+	"SISMEMBER":   cmdSIsMember,
+	"SMEMBERS":    cmdSMembers,
+	"FLUSHDB":     cmdFlushDB,
+	"DBSIZE":      cmdDBSize,
+}
+
+// NewServer returns a new server listen on addr, use 127.0.0.1:0 for a random port
+func NewServer(addr string) (*Server, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		listener: l,
+		dbs:      map[int]map[string]*item{},
+		conns:    map[net.Conn]bool{},
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s, nil
+}
+
+// Addr returns server listen address
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// RequirePass set password required by AUTH
+func (s *Server) RequirePass(password string) {
+	s.Lock()
+	s.password = password
+	s.Unlock()
+}
+
+// Close stop the server and close all connections
+func (s *Server) Close() error {
+	err := s.listener.Close()
+
+	s.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.Unlock()
+
+	s.wg.Wait()
+
+	return err
+}
+
+// serve accept connections
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		c, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.Lock()
+		s.conns[c] = true
+		s.Unlock()
+		s.wg.Add(1)
+		go s.serveConn(c)
+	}
+}
+
+// serveConn read commands and write replies
+func (s *Server) serveConn(c net.Conn) {
+	defer func() {
+		s.Lock()
+		delete(s.conns, c)
+		s.Unlock()
+		c.Close()
+		s.wg.Done()
+	}()
+
+	r := bufio.NewReader(c)
+	w := bufio.NewWriter(c)
+	ss := &session{}
+
+	for {
+		v, err := readReply(r)
+		if err != nil {
+			return
+		}
+
+		args, ok := toArgs(v)
+		if !ok || len(args) == 0 {
+			writeReply(w, ReplyError("ERR Protocol error"))
+			continue
+		}
+
+		writeReply(w, s.exec(ss, args))
+	}
+}
+
+// exec execute one command
+func (s *Server) exec(ss *session, args []string) interface{} {
+	s.Lock()
+	defer s.Unlock()
+
+	name := strings.ToUpper(args[0])
+	if name == "AUTH" {
+		return cmdAuth(s, ss, args)
+	}
+
+	if s.password != "" && !ss.authed {
+		return ReplyError("NOAUTH Authentication required.")
+	}
+
+	switch name {
+	case "MULTI", "EXEC", "DISCARD":
+		return s.transact(ss, name)
+	}
+
+	h, ok := handlers[name]
+	if !ok {
+		ss.aborted = ss.multi
+		return ReplyError(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+
+	if ss.multi {
+		ss.queued = append(ss.queued, args)
+		return statusReply("QUEUED")
+	}
+
+	return h(s, ss, args)
+}
+
+// transact handles MULTI, EXEC and DISCARD, queued commands are executed
+// by EXEC at once with the server lock held
+func (s *Server) transact(ss *session, name string) interface{} {
+	if name == "MULTI" {
+		if ss.multi {
+			return ReplyError("ERR MULTI calls can not be nested")
+		}
+		ss.multi = true
+		return okReply
+	}
+
+	if !ss.multi {
+		return ReplyError(fmt.Sprintf("ERR %s without MULTI", name))
+	}
+
+	queued, aborted := ss.queued, ss.aborted
+	ss.multi, ss.aborted, ss.queued = false, false, nil
+
+	if name == "DISCARD" {
+		return okReply
+	}
+
+	if aborted {
+		return ReplyError("EXECABORT Transaction discarded because of previous errors.")
+	}
+
+	r := make([]interface{}, len(queued))
+	for i, args := range queued {
+		r[i] = handlers[strings.ToUpper(args[0])](s, ss, args)
+	}
+
+	return r
+}
+
+// db returns keys of selected database
+func (s *Server) db(ss *session) map[string]*item {
+	if _, ok := s.dbs[ss.db]; !ok {
+		s.dbs[ss.db] = map[string]*item{}
+	}
+
+	return s.dbs[ss.db]
+}
+
+// lookup returns unexpired item of key
+func (s *Server) lookup(ss *session, key string) *item {
+	db := s.db(ss)
+	v, ok := db[key]
+	if !ok {
+		return nil
+	}
+
+	if v.expire > 0 && time.Now().UnixNano() >= v.expire {
+		delete(db, key)
+		return nil
+	}
+
+	return v
+}
+
+// cmdAuth handles AUTH password
+func cmdAuth(s *Server, ss *session, args []string) interface{} {
+	if len(args) != 2 {
+		return errArgs(args[0])
+	}
+
+	if s.password == "" {
+		return ReplyError("ERR AUTH <password> called without any password configured for the default user.")
+	}
+
+	if args[1] != s.password {
+		return ReplyError("WRONGPASS invalid username-password pair or user is disabled.")
+	}
+
+	ss.authed = true
+
+	return okReply
+}
+
+// cmdPing handles PING
+func cmdPing(s *Server, ss *session, args []string) interface{} {
+	return statusReply("PONG")
+}
+
+// cmdSelect handles SELECT index
+func cmdSelect(s *Server, ss *session, args []string) interface{} {
+	if len(args) != 2 {
+		return errArgs(args[0])
+	}
+
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 0 || n > 15 {
+		return ReplyError("ERR DB index is out of range")
+	}
+
+	ss.db = n
+
+	return okReply
+}
+
+// cmdSet handles SET key value [NX] [EX seconds|PX milliseconds]
+func cmdSet(s *Server, ss *session, args []string) interface{} {
+	if len(args) < 3 {
+		return errArgs(args[0])
+	}
+
+	nx := false
+	v := &item{value: args[2]}
+	for i := 3; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			nx = true
+		case "EX", "PX":
+			if i+1 >= len(args) {
+				return ReplyError("ERR syntax error")
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || n <= 0 {
+				return ReplyError("ERR invalid expire time in 'set' command")
+			}
+			d := time.Duration(n) * time.Second
+			if strings.ToUpper(args[i]) == "PX" {
+				d = time.Duration(n) * time.Millisecond
+			}
+			v.expire = time.Now().Add(d).UnixNano()
+			i++
+		default:
+			return ReplyError("ERR syntax error")
+		}
+	}
+
+	if nx && s.lookup(ss, args[1]) != nil {
+		return nil
+	}
+
+	s.db(ss)[args[1]] = v
+
+	return okReply
+}
+
+// cmdGet handles GET key
+func cmdGet(s *Server, ss *session, args []string) interface{} {
+	if len(args) != 2 {
+		return errArgs(args[0])
+	}
+
+	if v := s.lookup(ss, args[1]); v != nil {
+		if v.set != nil {
+			return errWrongType()
+		}
+		return v.value
+	}
+
+	return nil
+}
+
+// cmdGetDel handles GETDEL key
+func cmdGetDel(s *Server, ss *session, args []string) interface{} {
+	if len(args) != 2 {
+		return errArgs(args[0])
+	}
+
+	if v := s.lookup(ss, args[1]); v != nil {
+		delete(s.db(ss), args[1])
+		return v.value
+	}
+
+	return nil
+}
+
+// cmdMGet handles MGET key [key ...]
+func cmdMGet(s *Server, ss *session, args []string) interface{} {
+	if len(args) < 2 {
+		return errArgs(args[0])
+	}
+
+	r := []interface{}{}
+	for _, k := range args[1:] {
+		if v := s.lookup(ss, k); v != nil {
+			r = append(r, v.value)
+		} else {
+			r = append(r, nil)
+		}
+	}
+
+	return r
+}
+
+// cmdExists handles EXISTS key [key ...]
+func cmdExists(s *Server, ss *session, args []string) interface{} {
+	if len(args) < 2 {
+		return errArgs(args[0])
+	}
+
+	n := int64(0)
+	for _, k := range args[1:] {
+		if s.lookup(ss, k) != nil {
+			n++
+		}
+	}
+
+	return n
+}
+
+// cmdDel handles DEL key [key ...]
+func cmdDel(s *Server, ss *session, args []string) interface{} {
+	if len(args) < 2 {
+		return errArgs(args[0])
+	}
+
+	n := int64(0)
+	for _, k := range args[1:] {
+		if s.lookup(ss, k) != nil {
+			delete(s.db(ss), k)
+			n++
+		}
+	}
+
+	return n
+}
+
+// cmdIncr handles INCR key
+func cmdIncr(s *Server, ss *session, args []string) interface{} {
+	if len(args) != 2 {
+		return errArgs(args[0])
+	}
+
+	return s.incrBy(ss, args[1], 1)
+}
+
+// cmdDecr handles DECR key
+func cmdDecr(s *Server, ss *session, args []string) interface{} {
+	if len(args) != 2 {
+		return errArgs(args[0])
+	}
+
+	return s.incrBy(ss, args[1], -1)
+}
+
+// cmdIncrBy handles INCRBY key increment
+func cmdIncrBy(s *Server, ss *session, args []string) interface{} {
+	if len(args) != 3 {
+		return errArgs(args[0])
+	}
+
+	n, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return errNotInteger()
+	}
+
+	return s.incrBy(ss, args[1], n)
+}
+
+// cmdDecrBy handles DECRBY key decrement
+func cmdDecrBy(s *Server, ss *session, args []string) interface{} {
+	if len(args) != 3 {
+		return errArgs(args[0])
+	}
+
+	n, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return errNotInteger()
+	}
+
+	return s.incrBy(ss, args[1], -n)
+}
+
+// cmdIncrByFloat handles INCRBYFLOAT key increment
+func cmdIncrByFloat(s *Server, ss *session, args []string) interface{} {
+	if len(args) != 3 {
+		return errArgs(args[0])
+	}
+
+	n, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return ReplyError("ERR value is not a valid float")
+	}
+
+	v := s.lookup(ss, args[1])
+	if v == nil {
+		v = &item{value: "0"}
+		s.db(ss)[args[1]] = v
+	}
+
+	f, err := strconv.ParseFloat(v.value, 64)
+	if err != nil {
+		return ReplyError("ERR value is not a valid float")
+	}
+
+	v.value = strconv.FormatFloat(f+n, 'f', -1, 64)
+
+	return v.value
+}
+
+// cmdPTTL handles PTTL key
+func cmdPTTL(s *Server, ss *session, args []string) interface{} {
+	if len(args) != 2 {
+		return errArgs(args[0])
+	}
+
+	v := s.lookup(ss, args[1])
+	if v == nil {
+		return int64(-2)
+	}
+
+	if v.expire <= 0 {
+		return int64(-1)
+	}
+
+	return (v.expire - time.Now().UnixNano()) / int64(time.Millisecond)
+}
+
+// cmdPExpire handles PEXPIRE key milliseconds
+func cmdPExpire(s *Server, ss *session, args []string) interface{} {
+	if len(args) != 3 {
+		return errArgs(args[0])
+	}
+
+	n, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return errNotInteger()
+	}
+
+	v := s.lookup(ss, args[1])
+	if v == nil {
+		return int64(0)
+	}
+
+	if n <= 0 {
+		delete(s.db(ss), args[1])
+	} else {
+		v.expire = time.Now().Add(time.Duration(n) * time.Millisecond).UnixNano()
+	}
+
+	return int64(1)
+}
+
+// cmdPersist handles PERSIST key
+func cmdPersist(s *Server, ss *session, args []string) interface{} {
+	if len(args) != 2 {
+		return errArgs(args[0])
+	}
+
+	v := s.lookup(ss, args[1])
+	if v == nil || v.expire <= 0 {
+		return int64(0)
+	}
+
+	v.expire = 0
+
+	return int64(1)
+}
+
+// cmdKeys handles KEYS pattern
+func cmdKeys(s *Server, ss *session, args []string) interface{} {
+	if len(args) != 2 {
+		return errArgs(args[0])
+	}
+
+	ks := []string{}
+	for k := range s.db(ss) {
+		if s.lookup(ss, k) != nil && base.Match(args[1], k) {
+			ks = append(ks, k)
+		}
+	}
+
+	sort.Strings(ks)
+
+	r := make([]interface{}, len(ks))
+	for i, k := range ks {
+		r[i] = k
+	}
+
+	return r
+}
+
+// cmdSAdd handles SADD key member [member ...]
+func cmdSAdd(s *Server, ss *session, args []string) interface{} {
+	if len(args) < 3 {
+		return errArgs(args[0])
+	}
+
+	v := s.lookup(ss, args[1])
+	if v == nil {
+		v = &item{set: map[string]struct{}{}}
+		s.db(ss)[args[1]] = v
+	} else if v.set == nil {
+		return errWrongType()
+	}
+
+	n := int64(0)
+	for _, m := range args[2:] {
+		if _, ok := v.set[m]; !ok {
+			v.set[m] = struct{}{}
+			n++
+		}
+	}
+
+	return n
+}
+
+// cmdSIsMember handles SISMEMBER key member
+func cmdSIsMember(s *Server, ss *session, args []string) interface{} {
+	if len(args) != 3 {
+		return errArgs(args[0])
+	}
+
+	v := s.lookup(ss, args[1])
+	if v == nil {
+		return int64(0)
+	}
+
+	if v.set == nil {
+		return errWrongType()
+	}
+
+	if _, ok := v.set[args[2]]; ok {
+		return int64(1)
+	}
+
+	return int64(0)
+}
+
+// cmdSMembers handles SMEMBERS key
+func cmdSMembers(s *Server, ss *session, args []string) interface{} {
+	if len(args) != 2 {
+		return errArgs(args[0])
+	}
+
+	v := s.lookup(ss, args[1])
+	if v == nil {
+		return []interface{}{}
+	}
+
+	if v.set == nil {
+		return errWrongType()
+	}
+
+	ms := make([]string, 0, len(v.set))
+	for m := range v.set {
+		ms = append(ms, m)
+	}
+
+	sort.Strings(ms)
+
+	r := make([]interface{}, len(ms))
+	for i, m := range ms {
+		r[i] = m
+	}
+
+	return r
+}
+
+// cmdFlushDB handles FLUSHDB
+func cmdFlushDB(s *Server, ss *session, args []string) interface{} {
+	s.dbs[ss.db] = map[string]*item{}
+	return okReply
+}
+
+// cmdDBSize handles DBSIZE
+func cmdDBSize(s *Server, ss *session, args []string) interface{} {
+	n := int64(0)
+	for k := range s.db(ss) {
+		if s.lookup(ss, k) != nil {
+			n++
+		}
+	}
+
+	return n
+}
+
+// incrBy add delta to the integer value of key
+func (s *Server) incrBy(ss *session, key string, delta int64) interface{} {
+	v := s.lookup(ss, key)
+	if v == nil {
+		v = &item{value: "0"}
+		s.db(ss)[key] = v
+	}
+
+	n, err := strconv.ParseInt(v.value, 10, 64)
+	if err != nil {
+		return errNotInteger()
+	}
+
+	n += delta
+	v.value = strconv.FormatInt(n, 10)
+
+	return n
+}
+
+// errArgs returns wrong number of arguments error
+func errArgs(name string) ReplyError {
+	return ReplyError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(name)))
+}
+
+// errWrongType returns operation against a key holding the wrong kind of value error
+func errWrongType() ReplyError {
+	return ReplyError("WRONGTYPE Operation against a key holding the wrong kind of value")
+}
+
+// errNotInteger returns value is not an integer error
+func errNotInteger() ReplyError {
+	return ReplyError("ERR value is not an integer or out of range")
+}
+
+// toArgs returns command args from request
+func toArgs(v interface{}) ([]string, bool) {
+	vs, ok := v.([]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	args := make([]string, 0, len(vs))
+	for _, v := range vs {
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		args = append(args, s)
+	}
+
+	return args, true
+}
+
+// writeReply write reply to client
+func writeReply(w *bufio.Writer, v interface{}) {
+	writeValue(w, v)
+	_ = w.Flush()
+}
+
+// writeValue write value as RESP
+func writeValue(w *bufio.Writer, v interface{}) {
+	switch vv := v.(type) {
+	case nil:
+		_, _ = w.WriteString("$-1\r\n")
+	case statusReply:
+		_, _ = fmt.Fprintf(w, "+%s\r\n", string(vv))
+	case ReplyError:
+		_, _ = fmt.Fprintf(w, "-%s\r\n", string(vv))
+	case int64:
+		_, _ = fmt.Fprintf(w, ":%d\r\n", vv)
+	case string:
+		_, _ = fmt.Fprintf(w, "$%d\r\n%s\r\n", len(vv), vv)
+	case []interface{}:
+		_, _ = fmt.Fprintf(w, "*%d\r\n", len(vv))
+		for _, x := range vv {
+			writeValue(w, x)
+		}
+	default:
+		writeValue(w, ReplyError("ERR unknown reply"))
+	}
+}
@@ -20,27 +20,49 @@
 package xcache
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/likexian/gokit/xcache/base"
+	"github.com/likexian/gokit/xcache/file"
 	"github.com/likexian/gokit/xcache/memory"
+	"github.com/likexian/gokit/xcache/redis"
 )
 
+// Cache is typed memory cache
+type Cache[K comparable, V any] = memory.Cache[K, V]
+
 // Cacher list
 const (
 	MemoryCache = iota
+	FileCache
+	RedisCache
+)
+
+// Evict reason
+const (
+	Expired  = base.Expired
+	Deleted  = base.Deleted
+	Flushed  = base.Flushed
+	Capacity = base.Capacity
+	Replaced = base.Replaced
 )
 
+// NoExpire is ttl returned by TTL for key never expire
+const NoExpire = base.NoExpire
+
 // Cachex is cache interface
-type Cachex interface {
-	Get(key string) interface{}
-	MGet(key ...string) []interface{}
-	Set(key string, val interface{}, ttl int64) error
-	Has(key string) bool
-	Del(key string) error
-	Incr(key string) error
-	Decr(key string) error
-	SetGC(gcInterval, gcMaxOnce int)
-	Flush() error
-	Close() error
-}
+type Cachex = base.Cachex
+
+// EvictReason is reason of key removed from cache
+type EvictReason = base.EvictReason
+
+// EvictFunc is called after a key is removed from cache
+type EvictFunc = base.EvictFunc
+
+// Stats is cache statistics
+type Stats = base.Stats
 
 // Version returns package version
 func Version() string {
@@ -57,10 +79,75 @@ func License() string {
 	return "Licensed under the Apache License 2.0"
 }
 
-// New returns a new cacher
+// New returns a new cacher with default options
 func New(cacher int) Cachex {
+	c, _ := NewWithArgs(cacher)
+	return c
+}
+
+// NewWithArgs returns a new cacher, error is returned if args are not supported by the cacher
+// for MemoryCache, args are memory.Option
+// for FileCache, args[0] is the cache dir, default is xcache/<program name> in the user cache dir,
+// it is better to set explicitly since programs of the same name share the default dir
+// for RedisCache, args[0] is the server address or redis.Options, default is 127.0.0.1:6379
+func NewWithArgs(cacher int, args ...interface{}) (Cachex, error) {
 	switch cacher {
+	case RedisCache:
+		opts := redis.Options{}
+		if len(args) > 1 {
+			return nil, fmt.Errorf("xcache: too many arguments: %d", len(args))
+		}
+		if len(args) > 0 {
+			switch v := args[0].(type) {
+			case string:
+				opts.Addr = v
+			case redis.Options:
+				opts = v
+			default:
+				return nil, fmt.Errorf("xcache: unsupported argument: %T", v)
+			}
+		}
+		return redis.New(opts), nil
+	case FileCache:
+		dir := defaultDir()
+		if len(args) > 1 {
+			return nil, fmt.Errorf("xcache: too many arguments: %d", len(args))
+		}
+		if len(args) > 0 {
+			v, ok := args[0].(string)
+			if !ok {
+				return nil, fmt.Errorf("xcache: unsupported argument: %T", args[0])
+			}
+			if v != "" {
+				dir = v
+			}
+		}
+		return file.New(dir), nil
 	default:
-		return memory.New()
+		opts := []memory.Option{}
+		for _, v := range args {
+			o, ok := v.(memory.Option)
+			if !ok {
+				return nil, fmt.Errorf("xcache: unsupported argument: %T", v)
+			}
+			opts = append(opts, o)
+		}
+		return memory.New(opts...), nil
 	}
 }
+
+// defaultDir returns default dir of file cache, it is per program in the user cache dir,
+// or in the os temp dir if the user cache dir is unknown
+func defaultDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+
+	return filepath.Join(dir, "xcache", filepath.Base(os.Args[0]))
+}
+
+// NewCache returns a new typed memory cache
+func NewCache[K comparable, V any](opts ...memory.Option) *Cache[K, V] {
+	return memory.NewCache[K, V](opts...)
+}
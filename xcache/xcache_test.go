@@ -20,9 +20,18 @@
 package xcache
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/likexian/gokit/assert"
+	"github.com/likexian/gokit/xcache/base"
+	"github.com/likexian/gokit/xcache/memory"
+	"github.com/likexian/gokit/xcache/redis"
 )
 
 func TestVersion(t *testing.T) {
@@ -59,3 +68,370 @@ func TestNew(t *testing.T) {
 	v = c.Get("x")
 	assert.Equal(t, v, nil)
 }
+
+func TestNewOption(t *testing.T) {
+	c := mustNew(t, MemoryCache, memory.WithMaxEntries(1))
+	defer c.Close()
+
+	_ = c.Set("x", 1, 0)
+	_ = c.Set("y", 1, 0)
+	assert.False(t, c.Has("x"))
+	assert.True(t, c.Has("y"))
+}
+
+func TestNewWithArgs(t *testing.T) {
+	tests := []struct {
+		cacher int
+		args   []interface{}
+	}{
+		{MemoryCache, []interface{}{1}},
+		{MemoryCache, []interface{}{memory.WithShards(2), "x"}},
+		{FileCache, []interface{}{1}},
+		{FileCache, []interface{}{"a", "b"}},
+		{RedisCache, []interface{}{1}},
+		{RedisCache, []interface{}{"a", "b"}},
+	}
+
+	for _, v := range tests {
+		c, err := NewWithArgs(v.cacher, v.args...)
+		assert.NotNil(t, err, v)
+		assert.Nil(t, c, v)
+	}
+}
+
+// mustNew returns a new cacher with args, test is failed if error
+func mustNew(t *testing.T, cacher int, args ...interface{}) Cachex {
+	c, err := NewWithArgs(cacher, args...)
+	assert.Nil(t, err)
+	return c
+}
+
+func TestNewFile(t *testing.T) {
+	dir := t.TempDir()
+
+	c := mustNew(t, FileCache, dir)
+	err := c.Set("x", 1, 0)
+	assert.Nil(t, err)
+	c.Close()
+
+	c = mustNew(t, FileCache, dir)
+	defer c.Close()
+	assert.Equal(t, c.Get("x"), 1)
+}
+
+func TestDefaultDir(t *testing.T) {
+	dir := defaultDir()
+	assert.Equal(t, filepath.Base(dir), filepath.Base(os.Args[0]))
+	assert.Equal(t, filepath.Base(filepath.Dir(dir)), "xcache")
+}
+
+func TestNewRedis(t *testing.T) {
+	s, err := redis.NewServer("127.0.0.1:0")
+	assert.Nil(t, err)
+	defer s.Close()
+
+	for _, v := range []interface{}{s.Addr(), redis.Options{Addr: s.Addr()}} {
+		c := mustNew(t, RedisCache, v)
+		err = c.Set("x", 1, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, c.Get("x"), "1")
+		c.Close()
+	}
+}
+
+func TestNewCache(t *testing.T) {
+	c := NewCache[string, int]()
+	defer c.Close()
+
+	v, ok := c.Get("x")
+	assert.False(t, ok)
+	assert.Equal(t, v, 0)
+
+	err := c.Set("x", 1, 0)
+	assert.Nil(t, err)
+
+	v, ok = c.Get("x")
+	assert.True(t, ok)
+	assert.Equal(t, v, 1)
+}
+
+func TestKeyAPI(t *testing.T) {
+	s, err := redis.NewServer("127.0.0.1:0")
+	assert.Nil(t, err)
+	defer s.Close()
+
+	cs := map[string]Cachex{
+		"memory": New(MemoryCache),
+		"file":   mustNew(t, FileCache, t.TempDir()),
+		"redis":  mustNew(t, RedisCache, s.Addr()),
+	}
+
+	for name, c := range cs {
+		t.Run(name, func(t *testing.T) {
+			defer c.Close()
+
+			// ttl
+			_, err := c.TTL("x")
+			assert.Equal(t, err, base.ErrKeyNotExists)
+
+			err = c.Set("x", 1, 0)
+			assert.Nil(t, err)
+			ttl, err := c.TTL("x")
+			assert.Nil(t, err)
+			assert.Equal(t, ttl, NoExpire)
+
+			// expire and persist
+			err = c.Expire("x", time.Minute)
+			assert.Nil(t, err)
+			ttl, err = c.TTL("x")
+			assert.Nil(t, err)
+			assert.True(t, ttl > 59*time.Second && ttl <= time.Minute)
+
+			err = c.Persist("x")
+			assert.Nil(t, err)
+			ttl, _ = c.TTL("x")
+			assert.Equal(t, ttl, NoExpire)
+
+			err = c.Expire("x", 0)
+			assert.Nil(t, err)
+			assert.False(t, c.Has("x"))
+
+			err = c.Expire("x", time.Minute)
+			assert.Equal(t, err, base.ErrKeyNotExists)
+			err = c.Persist("x")
+			assert.Equal(t, err, base.ErrKeyNotExists)
+
+			// sub-second ttl
+			err = c.SetWithTTL("x", 1, 100*time.Millisecond)
+			assert.Nil(t, err)
+			assert.True(t, c.Has("x"))
+			time.Sleep(200 * time.Millisecond)
+			assert.False(t, c.Has("x"))
+
+			// setnx
+			ok, err := c.SetNX("x", 1, time.Minute)
+			assert.Nil(t, err)
+			assert.True(t, ok)
+			ok, err = c.SetNX("x", 2, time.Minute)
+			assert.Nil(t, err)
+			assert.False(t, ok)
+
+			// incrby
+			n, err := c.IncrBy("n", 10)
+			assert.Nil(t, err)
+			assert.Equal(t, n, int64(10))
+			n, err = c.IncrBy("n", -3)
+			assert.Nil(t, err)
+			assert.Equal(t, n, int64(7))
+
+			f, err := c.IncrByFloat("f", 1.5)
+			assert.Nil(t, err)
+			assert.Equal(t, f, 1.5)
+			f, err = c.IncrByFloat("f", 0.25)
+			assert.Nil(t, err)
+			assert.Equal(t, f, 1.75)
+
+			// keys
+			for _, k := range []string{"user:1", "user:2", "user:10", "order:1"} {
+				_ = c.Set(k, 1, 0)
+			}
+			ks, err := c.Keys("user:?")
+			assert.Nil(t, err)
+			assert.Equal(t, ks, []string{"user:1", "user:2"})
+			ks, err = c.Keys("*:1*")
+			assert.Nil(t, err)
+			assert.Equal(t, ks, []string{"order:1", "user:1", "user:10"})
+			ks, err = c.Keys("none*")
+			assert.Nil(t, err)
+			assert.Equal(t, len(ks), 0)
+		})
+	}
+}
+
+func TestSetNXRace(t *testing.T) {
+	s, err := redis.NewServer("127.0.0.1:0")
+	assert.Nil(t, err)
+	defer s.Close()
+
+	cs := map[string]Cachex{
+		"memory": New(MemoryCache),
+		"file":   mustNew(t, FileCache, t.TempDir()),
+		"redis":  mustNew(t, RedisCache, s.Addr()),
+	}
+
+	for name, c := range cs {
+		t.Run(name, func(t *testing.T) {
+			defer c.Close()
+
+			var wg sync.WaitGroup
+			var won int32
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					ok, err := c.SetNX("lock", i, time.Minute)
+					assert.Nil(t, err)
+					if ok {
+						atomic.AddInt32(&won, 1)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			assert.Equal(t, won, int32(1))
+		})
+	}
+}
+
+func TestIncrByRateLimit(t *testing.T) {
+	c := New(MemoryCache)
+	defer c.Close()
+
+	allow := func(key string, limit int64) bool {
+		n, err := c.IncrBy(key, 1)
+		assert.Nil(t, err)
+		if n == 1 {
+			_ = c.Expire(key, 200*time.Millisecond)
+		}
+		return n <= limit
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, allow("rate:ip", 3))
+	}
+	assert.False(t, allow("rate:ip", 3))
+
+	time.Sleep(300 * time.Millisecond)
+	assert.True(t, allow("rate:ip", 3))
+}
+
+func TestTags(t *testing.T) {
+	s, err := redis.NewServer("127.0.0.1:0")
+	assert.Nil(t, err)
+	defer s.Close()
+
+	cs := map[string]Cachex{
+		"memory": New(MemoryCache),
+		"file":   mustNew(t, FileCache, t.TempDir()),
+		"redis":  mustNew(t, RedisCache, s.Addr()),
+	}
+
+	for name, c := range cs {
+		t.Run(name, func(t *testing.T) {
+			defer c.Close()
+
+			_ = c.SetWithTags("a", 1, time.Minute, "user:1", "list")
+			_ = c.SetWithTags("b", 1, 0, "user:1")
+			_ = c.SetWithTags("c", 1, 0, "user:2", "list")
+			_ = c.Set("d", 1, 0)
+
+			err := c.InvalidateTag("user:1")
+			assert.Nil(t, err)
+			assert.False(t, c.Has("a"))
+			assert.False(t, c.Has("b"))
+			assert.True(t, c.Has("c"))
+			assert.True(t, c.Has("d"))
+
+			err = c.InvalidateTag("list")
+			assert.Nil(t, err)
+			assert.False(t, c.Has("c"))
+			assert.True(t, c.Has("d"))
+
+			err = c.InvalidateTag("none")
+			assert.Nil(t, err)
+
+			// tags are kept by ttl and counter updates
+			_ = c.SetWithTags("n", 1, 0, "counter")
+			_ = c.Expire("n", time.Minute)
+			_, _ = c.IncrBy("n", 1)
+			_ = c.InvalidateTag("counter")
+			assert.False(t, c.Has("n"))
+
+			// key set again without the tag is kept
+			_ = c.SetWithTags("e", 1, 0, "retag")
+			_ = c.Set("e", 2, 0)
+			_ = c.SetWithTags("f", 1, 0, "retag")
+			_ = c.SetWithTags("f", 2, 0, "other")
+			_ = c.InvalidateTag("retag")
+			assert.True(t, c.Has("e"))
+			assert.True(t, c.Has("f"))
+			_ = c.InvalidateTag("other")
+			assert.False(t, c.Has("f"))
+			_ = c.Del("e")
+
+			ks, err := c.Keys("*")
+			assert.Nil(t, err)
+			assert.Equal(t, ks, []string{"d"})
+		})
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	s, err := redis.NewServer("127.0.0.1:0")
+	assert.Nil(t, err)
+	defer s.Close()
+
+	cs := map[string]Cachex{
+		"memory": New(MemoryCache),
+		"file":   mustNew(t, FileCache, t.TempDir()),
+		"redis":  mustNew(t, RedisCache, s.Addr()),
+	}
+
+	for name, c := range cs {
+		t.Run(name, func(t *testing.T) {
+			defer c.Close()
+
+			t1 := c.Namespace("tenant:1")
+			t2 := c.Namespace("tenant:2")
+
+			_ = c.Set("x", "root", 0)
+			_ = t1.Set("x", "t1", 0)
+			_ = t2.Set("x", "t2", 0)
+			_ = t1.SetWithTags("y", "t1", 0, "user")
+			_ = t2.SetWithTags("y", "t2", 0, "user")
+
+			assert.Equal(t, fmt.Sprint(c.Get("x")), "root")
+			assert.Equal(t, fmt.Sprint(t1.Get("x")), "t1")
+			assert.Equal(t, fmt.Sprint(c.Get("tenant:1:x")), "t1")
+			assert.Equal(t, len(t1.MGet("x", "y", "z")), 3)
+
+			ks, err := t1.Keys("*")
+			assert.Nil(t, err)
+			assert.Equal(t, ks, []string{"x", "y"})
+			assert.Equal(t, t1.Stats().Keys, 2)
+
+			// tags are scoped to namespace
+			err = t1.InvalidateTag("user")
+			assert.Nil(t, err)
+			assert.False(t, t1.Has("y"))
+			assert.True(t, t2.Has("y"))
+
+			// nested namespace
+			n := t1.Namespace("sub")
+			_ = n.Set("z", 1, 0)
+			assert.True(t, c.Has("tenant:1:sub:z"))
+
+			// flush only the namespace
+			err = t1.Flush()
+			assert.Nil(t, err)
+			assert.False(t, t1.Has("x"))
+			assert.False(t, n.Has("z"))
+			assert.True(t, t2.Has("x"))
+			assert.True(t, c.Has("x"))
+
+			// close of view keeps the cache
+			assert.Nil(t, t2.Close())
+			assert.True(t, t2.Has("x"))
+
+			// func of cache is not replaced by views
+			var evicted []string
+			c.OnEvict(func(key string, value interface{}, reason EvictReason) {
+				evicted = append(evicted, key)
+			})
+			t1.OnEvict(nil)
+			t2.OnEvict(func(key string, value interface{}, reason EvictReason) {})
+			_ = t2.Del("x")
+			assert.Equal(t, evicted, []string{"tenant:2:x"})
+		})
+	}
+}
@@ -0,0 +1,299 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xcron
+
+import (
+	"sync"
+	"time"
+)
+
+// fakeTimeout is max real time FakeClock waits for the fired timer to be reset or stopped
+const fakeTimeout = time.Second
+
+// Clock is source of time of cron service
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// NewTimer returns a timer fires after duration
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is timer of Clock
+type Timer interface {
+	// C returns channel the time is sent to when timer fires
+	C() <-chan time.Time
+	// Reset change timer to fire after duration
+	Reset(d time.Duration) bool
+	// Stop stop the timer
+	Stop() bool
+}
+
+// tracker is Clock tracking the runs of jobs in progress
+type tracker interface {
+	// track add a run in progress, returns the func called after the run finished
+	track() func()
+}
+
+// manualClock is Clock whose time changes only by firing its timers, so the scheduler
+// sleeps until the earliest job is due, instead of waking up periodically for clock jumps
+type manualClock interface {
+	// manual marks the clock as manual
+	manual()
+}
+
+// realClock is Clock of wall time
+type realClock struct{}
+
+// realTimer is Timer of wall time
+type realTimer struct {
+	*time.Timer
+}
+
+// FakeClock is Clock of time advanced by hand, for testing scheduled jobs
+type FakeClock struct {
+	now    time.Time
+	timers []*fakeTimer
+	busy   int
+	gen    int
+	idle   chan bool
+	mu     sync.Mutex
+}
+
+// fakeTimer is Timer of FakeClock
+type fakeTimer struct {
+	clock  *FakeClock
+	when   time.Time
+	c      chan time.Time
+	active bool
+	ack    chan bool
+}
+
+// Now returns the current wall time
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer returns a timer of wall time
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+// C returns channel of timer
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// NewFakeClock returns new fake clock starts at the time
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current time of fake clock
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer returns a timer fires when fake clock is advanced after duration
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1)}
+
+	c.mu.Lock()
+	c.timers = append(c.timers, t)
+	c.mu.Unlock()
+
+	t.Reset(d)
+
+	return t
+}
+
+// Advance move fake clock forward by duration, timers are fired one by one in order of time,
+// and it waits for each fired timer to be reset or stopped and the runs of jobs due at the time
+// to finish, so the cron service runs jobs at the time as if the time goes by, the wait of each
+// step is up to 1 second of real time, in case of a run is blocked
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	end := c.now.Add(d)
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+		if t := c.fired(); t != nil {
+			ack := t.ack
+			c.mu.Unlock()
+			select {
+			case <-ack:
+			case <-time.After(fakeTimeout):
+				c.mu.Lock()
+				t.ack = nil
+				c.mu.Unlock()
+			}
+			continue
+		}
+
+		if c.busy > 0 {
+			idle := c.idle
+			c.mu.Unlock()
+			select {
+			case <-idle:
+			case <-time.After(fakeTimeout):
+				c.mu.Lock()
+				// runs in progress are forgotten, they are not counted when they finish
+				if c.idle == idle {
+					c.busy = 0
+					c.gen++
+					close(c.idle)
+					c.idle = nil
+				}
+				c.mu.Unlock()
+			}
+			continue
+		}
+
+		t := c.next(end)
+		if t == nil {
+			c.now = end
+			c.mu.Unlock()
+			return
+		}
+
+		c.now = t.when
+		t.fire()
+		c.mu.Unlock()
+	}
+}
+
+// manual marks fake clock as manual, its time changes only by Advance
+func (c *FakeClock) manual() {}
+
+// track add a run in progress, Advance waits until all runs finished,
+// returns the func called after the run finished
+func (c *FakeClock) track() func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.busy == 0 {
+		c.idle = make(chan bool)
+	}
+
+	c.busy++
+	gen := c.gen
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			// the run is forgotten by Advance after timeout
+			if c.gen != gen {
+				return
+			}
+
+			c.busy--
+			if c.busy == 0 {
+				close(c.idle)
+				c.idle = nil
+			}
+		})
+	}
+}
+
+// fired returns timer fired but not yet reset or stopped, the lock must be held
+func (c *FakeClock) fired() *fakeTimer {
+	for _, t := range c.timers {
+		if t.ack != nil {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// next returns the earliest active timer fires before the time, the lock must be held
+func (c *FakeClock) next(end time.Time) *fakeTimer {
+	var r *fakeTimer
+	for _, t := range c.timers {
+		if t.active && !t.when.After(end) && (r == nil || t.when.Before(r.when)) {
+			r = t
+		}
+	}
+
+	return r
+}
+
+// C returns channel of fake timer
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+// Reset change fake timer to fire after duration, it fires at once if duration is not positive
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active := t.active
+	t.stop()
+	t.when = c.now.Add(d)
+	t.active = true
+	if d <= 0 {
+		t.fire()
+	}
+
+	return active
+}
+
+// Stop stop fake timer
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	active := t.active
+	t.stop()
+
+	return active
+}
+
+// fire send the time to channel of fake timer, the lock of clock must be held
+func (t *fakeTimer) fire() {
+	t.active = false
+	t.ack = make(chan bool)
+	select {
+	case t.c <- t.clock.now:
+	default:
+	}
+}
+
+// stop deactivate fake timer, drain the pending time and acknowledge the fire,
+// the lock of clock must be held
+func (t *fakeTimer) stop() {
+	t.active = false
+	select {
+	case <-t.c:
+	default:
+	}
+
+	if t.ack != nil {
+		close(t.ack)
+		t.ack = nil
+	}
+}
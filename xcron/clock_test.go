@@ -0,0 +1,226 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xcron
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/likexian/gokit/assert"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	assert.Equal(t, clock.Now(), start)
+
+	timer := clock.NewTimer(time.Hour)
+	clock.Advance(30 * time.Minute)
+	assert.Equal(t, clock.Now(), start.Add(30*time.Minute))
+
+	select {
+	case <-timer.C():
+		t.Error("timer fired too early")
+	default:
+	}
+
+	go func() {
+		v := <-timer.C()
+		assert.Equal(t, v, start.Add(time.Hour))
+		assert.False(t, timer.Stop())
+	}()
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, clock.Now(), start.Add(90*time.Minute))
+	assert.False(t, timer.Reset(time.Minute))
+	assert.True(t, timer.Stop())
+}
+
+func TestFakeClockService(t *testing.T) {
+	tests := []struct {
+		rule string
+		runs []time.Time
+	}{
+		{
+			"0 30 9 * * *",
+			[]time.Time{
+				time.Date(2026, 1, 1, 9, 30, 0, 0, time.UTC),
+				time.Date(2026, 1, 2, 9, 30, 0, 0, time.UTC),
+				time.Date(2026, 1, 3, 9, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			"0 0 0 L * *",
+			[]time.Time{
+				time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, v := range tests {
+		t.Run(v.rule, func(t *testing.T) {
+			clock := NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+			c := NewWithClock(clock)
+
+			runs := make(chan time.Time, 10)
+			err := c.SetWithOptions("x", v.rule, func() {
+				runs <- clock.Now()
+			}, WithLocation(time.UTC))
+			assert.Nil(t, err)
+
+			for _, w := range v.runs {
+				clock.Advance(w.Sub(clock.Now()))
+				assert.Equal(t, <-runs, w)
+			}
+
+			c.Empty()
+			c.Wait()
+			assert.Equal(t, len(runs), 0)
+		})
+	}
+}
+
+func TestFakeClockAdvanceMany(t *testing.T) {
+	tests := []struct {
+		rule    string
+		advance time.Duration
+		runs    int32
+	}{
+		{"0 0 * * * *", 24 * time.Hour, 24},
+		{"0 0 0 1 * *", 365 * 24 * time.Hour, 12},
+		{"@every 10s", time.Hour, 360},
+	}
+
+	for _, v := range tests {
+		t.Run(v.rule, func(t *testing.T) {
+			clock := NewFakeClock(time.Date(2026, 1, 1, 0, 0, 1, 0, time.UTC))
+			c := NewWithClock(clock)
+
+			var skipped int32
+			c.OnEvent(func(e Event) {
+				if e.Type == Skipped {
+					atomic.AddInt32(&skipped, 1)
+				}
+			})
+
+			var runs int32
+			err := c.SetWithOptions("x", v.rule, func() {
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&runs, 1)
+			}, WithLocation(time.UTC))
+			assert.Nil(t, err)
+
+			clock.Advance(v.advance)
+			assert.Equal(t, atomic.LoadInt32(&runs), v.runs)
+			assert.Equal(t, atomic.LoadInt32(&skipped), int32(0))
+
+			c.Empty()
+			c.Wait()
+		})
+	}
+}
+
+// recordClock is Clock recording positive durations its timers are reset to
+type recordClock struct {
+	Clock
+	resets chan time.Duration
+}
+
+// recordTimer is Timer of recordClock
+type recordTimer struct {
+	Timer
+	resets chan time.Duration
+}
+
+// manualRecordClock is manual recordClock
+type manualRecordClock struct {
+	*recordClock
+}
+
+// NewTimer returns a recording timer
+func (c *recordClock) NewTimer(d time.Duration) Timer {
+	return &recordTimer{Timer: c.Clock.NewTimer(d), resets: c.resets}
+}
+
+// Reset records the duration and resets the timer
+func (t *recordTimer) Reset(d time.Duration) bool {
+	if d > 0 {
+		select {
+		case t.resets <- d:
+		default:
+		}
+	}
+
+	return t.Timer.Reset(d)
+}
+
+// manual marks the clock as manual
+func (manualRecordClock) manual() {}
+
+func TestClockSleep(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, manual := range []bool{false, true} {
+		r := &recordClock{Clock: NewFakeClock(start), resets: make(chan time.Duration, 10)}
+		var clock Clock = r
+		if manual {
+			clock = manualRecordClock{r}
+		}
+
+		c := NewWithClock(clock)
+		_, err := c.Add("@yearly", func() {})
+		assert.Nil(t, err)
+
+		// clock not manual wakes up the scheduler periodically
+		d := <-r.resets
+		if manual {
+			assert.Equal(t, d, start.AddDate(1, 0, 0).Sub(start))
+		} else {
+			assert.Equal(t, d, maxSleep)
+		}
+
+		c.Empty()
+		c.Wait()
+	}
+}
+
+func TestFakeClockTrackTimeout(t *testing.T) {
+	clock := NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	// run blocked longer than timeout is forgotten
+	late := clock.track()
+	clock.Advance(time.Second)
+	late()
+	late()
+
+	var finished int32
+	done := clock.track()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		atomic.StoreInt32(&finished, 1)
+		done()
+	}()
+
+	// the late finish does not hide the next run
+	clock.Advance(time.Second)
+	assert.Equal(t, atomic.LoadInt32(&finished), int32(1))
+}
@@ -0,0 +1,214 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xcron
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Registry is map of job name to its func
+type Registry map[string]func()
+
+// Crontab is jobs of service loaded from crontab file
+type Crontab struct {
+	service  *Service
+	fpath    string
+	registry Registry
+	rules    map[string]string
+	data     []byte
+	seen     []byte
+	onReload func(err error)
+	stop     chan bool
+	done     chan bool
+	once     sync.Once
+	mu       sync.Mutex
+}
+
+// LoadCrontab load jobs from crontab file into the service, each line of file is a rule followed by
+// job name, for example "0 30 9 * * mon-fri report", name is the id of job in the service and maps to
+// func in registry, empty lines and lines starting with # are ignored. The file is checked every interval
+// and reloaded when changed, 0 for never, call Reload to apply the changes, for example on SIGHUP
+func LoadCrontab(s *Service, fpath string, registry Registry, interval time.Duration) (*Crontab, error) {
+	c := &Crontab{
+		service:  s,
+		fpath:    fpath,
+		registry: registry,
+		rules:    map[string]string{},
+		stop:     make(chan bool),
+		done:     make(chan bool),
+	}
+
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+
+	if interval > 0 {
+		go c.watch(interval)
+	} else {
+		close(c.done)
+	}
+
+	return c, nil
+}
+
+// Reload reload the crontab file, only jobs added, removed or modified are applied, jobs unchanged
+// keep their state, nothing is applied if any line is invalid
+func (c *Crontab) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := os.ReadFile(c.fpath)
+	if err != nil {
+		return err
+	}
+
+	c.seen = data
+	if c.data != nil && bytes.Equal(data, c.data) {
+		return nil
+	}
+
+	rules, err := c.parse(data)
+	if err != nil {
+		return err
+	}
+
+	for name := range c.rules {
+		if _, ok := rules[name]; !ok {
+			c.service.Del(name)
+		}
+	}
+
+	for name, rule := range rules {
+		if c.rules[name] == rule && c.service.Has(name) {
+			continue
+		}
+		if err := c.service.Set(name, rule, c.registry[name]); err != nil {
+			return err
+		}
+	}
+
+	c.rules = rules
+	c.data = data
+
+	return nil
+}
+
+// OnReload set the func called after crontab file is reloaded for changes, err is nil if succeed,
+// the jobs loaded before are kept if failed
+func (c *Crontab) OnReload(f func(err error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onReload = f
+}
+
+// Close stop reloading crontab file and delete the jobs loaded from it from the service
+func (c *Crontab) Close() {
+	c.once.Do(func() {
+		close(c.stop)
+		<-c.done
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for name := range c.rules {
+			c.service.Del(name)
+		}
+		c.rules = map[string]string{}
+		c.data = nil
+	})
+}
+
+// watch reload crontab file every interval when changed until closed
+func (c *Crontab) watch(interval time.Duration) {
+	defer close(c.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.check()
+		}
+	}
+}
+
+// check reload crontab file if changed since last seen, a failure is reported once until changed again
+func (c *Crontab) check() {
+	c.mu.Lock()
+	data, err := os.ReadFile(c.fpath)
+	changed := !bytes.Equal(data, c.seen)
+	if err != nil {
+		c.seen = nil
+	}
+	f := c.onReload
+	c.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if err == nil {
+		err = c.Reload()
+	}
+
+	if f != nil {
+		f(err)
+	}
+}
+
+// parse parse crontab file, returns map of job name to rule
+func (c *Crontab) parse(data []byte) (map[string]string, error) {
+	rules := map[string]string{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		n := strings.LastIndexAny(line, " \t")
+		if n < 0 {
+			return nil, fmt.Errorf("xcron: crontab line %d: missing job name: %s", i+1, line)
+		}
+
+		rule, name := strings.TrimSpace(line[:n]), line[n+1:]
+		if _, ok := c.registry[name]; !ok {
+			return nil, fmt.Errorf("xcron: crontab line %d: unregistered job: %s", i+1, name)
+		}
+
+		if _, ok := rules[name]; ok {
+			return nil, fmt.Errorf("xcron: crontab line %d: duplicate job: %s", i+1, name)
+		}
+
+		if _, err := Parse(rule); err != nil {
+			return nil, fmt.Errorf("xcron: crontab line %d: %s", i+1, strings.TrimPrefix(err.Error(), "xcron: "))
+		}
+
+		rules[name] = rule
+	}
+
+	return rules, nil
+}
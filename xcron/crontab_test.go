@@ -0,0 +1,152 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xcron
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/likexian/gokit/assert"
+)
+
+func TestCrontab(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "crontab")
+	registry := Registry{"a": func() {}, "b": func() {}, "c": func() {}}
+
+	s := New()
+	defer s.Wait()
+	defer s.Empty()
+
+	_, err := LoadCrontab(s, fpath, registry, 10*time.Millisecond)
+	assert.NotNil(t, err)
+
+	data := "# jobs\n\n0 0 * * * a\nCRON_TZ=UTC 0 0 9 * * mon-fri b\n"
+	assert.Nil(t, os.WriteFile(fpath, []byte(data), 0o644))
+
+	c, err := LoadCrontab(s, fpath, registry, 10*time.Millisecond)
+	assert.Nil(t, err)
+	defer c.Close()
+
+	reload := make(chan error, 1)
+	c.OnReload(func(err error) {
+		reload <- err
+	})
+
+	jobs := s.Jobs()
+	assert.Equal(t, len(jobs), 2)
+	assert.Equal(t, jobs[0].ID, "a")
+	assert.Equal(t, jobs[1].Rule, "CRON_TZ=UTC 0 0 9 * * mon-fri")
+
+	s.RLock()
+	a, b := s.jobs["a"], s.jobs["b"]
+	s.RUnlock()
+
+	data = "0 0 * * * a\n@every 1m c\n0 0 10 * * mon-fri b\n"
+	assert.Nil(t, os.WriteFile(fpath, []byte(data), 0o644))
+	assert.Nil(t, <-reload)
+
+	jobs = s.Jobs()
+	assert.Equal(t, len(jobs), 3)
+	assert.Equal(t, jobs[1].Rule, "0 0 10 * * mon-fri")
+	assert.Equal(t, jobs[2].Rule, "@every 1m")
+
+	s.RLock()
+	assert.True(t, s.jobs["a"] == a)
+	assert.True(t, s.jobs["b"] != b)
+	s.RUnlock()
+
+	data = "0 0 * * * a\n"
+	assert.Nil(t, os.WriteFile(fpath, []byte(data), 0o644))
+	assert.Nil(t, <-reload)
+	assert.Equal(t, s.Len(), 1)
+	assert.True(t, s.Has("a"))
+
+	data = "0 0 * * * a\n0 0 * * * x\n"
+	assert.Nil(t, os.WriteFile(fpath, []byte(data), 0o644))
+	err = <-reload
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(), "xcron: crontab line 2: unregistered job: x")
+	assert.Equal(t, s.Len(), 1)
+
+	select {
+	case err = <-reload:
+		t.Errorf("unexpected reload: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	assert.Nil(t, os.Remove(fpath))
+	assert.NotNil(t, <-reload)
+	assert.Equal(t, s.Len(), 1)
+}
+
+func TestCrontabError(t *testing.T) {
+	registry := Registry{"a": func() {}}
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{"0 0 * * * a\na", "xcron: crontab line 2: missing job name: a"},
+		{"\n0 0 * * * b", "xcron: crontab line 2: unregistered job: b"},
+		{"0 0 * * * a\n# a\n0 1 * * * a", "xcron: crontab line 3: duplicate job: a"},
+		{"0 0 * * x a", "xcron: crontab line 1: invalid day of week field: unrecognized charset: x"},
+	}
+
+	for _, v := range tests {
+		fpath := filepath.Join(t.TempDir(), "crontab")
+		assert.Nil(t, os.WriteFile(fpath, []byte(v.in), 0o644))
+		_, err := LoadCrontab(New(), fpath, registry, 0)
+		assert.NotNil(t, err)
+		assert.Equal(t, err.Error(), v.out)
+	}
+}
+
+func TestCrontabShared(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "crontab")
+	registry := Registry{"a": func() {}, "b": func() {}}
+	assert.Nil(t, os.WriteFile(fpath, []byte("0 0 * * * a\n"), 0o644))
+
+	s := New()
+	defer s.Wait()
+	defer s.Empty()
+
+	err := s.Set("x", "@hourly", func() {})
+	assert.Nil(t, err)
+
+	c, err := LoadCrontab(s, fpath, registry, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, s.Len(), 2)
+
+	// file is not checked without interval
+	assert.Nil(t, os.WriteFile(fpath, []byte("0 0 * * * a\n0 0 * * * b\n"), 0o644))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, s.Len(), 2)
+
+	assert.Nil(t, c.Reload())
+	assert.Equal(t, s.Len(), 3)
+	assert.True(t, s.Has("b"))
+
+	// only jobs loaded from the file are deleted
+	c.Close()
+	c.Close()
+	assert.Equal(t, s.Len(), 1)
+	assert.True(t, s.Has("x"))
+}
@@ -0,0 +1,355 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xcron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Wording is words of rule description, set fields to describe rules in other languages,
+// %s and %d are replaced by values as fmt.Sprintf
+type Wording struct {
+	// Months is names of months, January first
+	Months [12]string
+	// Weekdays is names of days of week, Sunday first
+	Weekdays [7]string
+	// Ordinals is ordinal numbers of the nth day of week, from 1 to 5
+	Ordinals [5]string
+	// Last is ordinal of the last day of week
+	Last string
+	// Sep is separator of list items and description parts
+	Sep string
+	// And joins the last two list items
+	And string
+	// Or joins alternative days of month
+	Or string
+	// Through is range of values
+	Through string
+	// EverySecond is every second
+	EverySecond string
+	// EveryMinute is every minute
+	EveryMinute string
+	// EveryHour is every hour
+	EveryHour string
+	// EverySeconds is every n seconds
+	EverySeconds string
+	// EveryMinutes is every n minutes
+	EveryMinutes string
+	// EveryHours is every n hours
+	EveryHours string
+	// EveryDays is every n days of month
+	EveryDays string
+	// EveryMonths is every n months
+	EveryMonths string
+	// Every is interval of @every duration rule
+	Every string
+	// At is at list of times
+	At string
+	// SecondsPast is seconds past the minute
+	SecondsPast string
+	// MinutesPast is minutes past the hour
+	MinutesPast string
+	// Between is range of hours
+	Between string
+	// InHours is list of hours
+	InHours string
+	// OnDays is days of month
+	OnDays string
+	// LastDay is the last day of month
+	LastDay string
+	// DaysBeforeLast is n days before the last day of month
+	DaysBeforeLast string
+	// NearestWeekday is the nearest weekday of day of month
+	NearestWeekday string
+	// LastWeekday is the last weekday of month
+	LastWeekday string
+	// NthWeekday is the nth day of week of month
+	NthWeekday string
+	// OnWeekdays is days of week
+	OnWeekdays string
+	// InMonths is months
+	InMonths string
+	// InLocation is time zone of rule
+	InLocation string
+}
+
+// English is English wording of rule description
+var English = Wording{
+	Months: [12]string{"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December"},
+	Weekdays:       [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+	Ordinals:       [5]string{"first", "second", "third", "fourth", "fifth"},
+	Last:           "last",
+	Sep:            ", ",
+	And:            "%s and %s",
+	Or:             "%s or %s",
+	Through:        "%s through %s",
+	EverySecond:    "every second",
+	EveryMinute:    "every minute",
+	EveryHour:      "every hour",
+	EverySeconds:   "every %d seconds",
+	EveryMinutes:   "every %d minutes",
+	EveryHours:     "every %d hours",
+	EveryDays:      "every %d days",
+	EveryMonths:    "every %d months",
+	Every:          "every %s",
+	At:             "at %s",
+	SecondsPast:    "at %s seconds past the minute",
+	MinutesPast:    "at %s minutes past the hour",
+	Between:        "between %s and %s",
+	InHours:        "in hours %s",
+	OnDays:         "on day %s of the month",
+	LastDay:        "on the last day of the month",
+	DaysBeforeLast: "%d days before the last day of the month",
+	NearestWeekday: "on the weekday nearest day %d of the month",
+	LastWeekday:    "on the last weekday of the month",
+	NthWeekday:     "on the %s %s of the month",
+	OnWeekdays:     "only on %s",
+	InMonths:       "only in %s",
+	InLocation:     "in %s time",
+}
+
+// values is kind of field values
+type values int
+
+// Kind of field values
+const (
+	anyValue values = iota
+	oneValue
+	stepValue
+	rangeValue
+	listValue
+)
+
+// Describe returns English description of rule, for example
+// "every 15 minutes between 09:00 and 17:59, Monday through Friday"
+func (r Rule) Describe() string {
+	return r.DescribeIn(English)
+}
+
+// DescribeIn returns description of rule in the wording
+func (r Rule) DescribeIn(w Wording) string {
+	var ps []string
+	if r.Every > 0 {
+		ps = []string{fmt.Sprintf(w.Every, r.Every)}
+	} else {
+		ps = append(r.describeTime(w), r.describeDate(w)...)
+	}
+
+	if r.Location != nil {
+		ps = append(ps, fmt.Sprintf(w.InLocation, r.Location))
+	}
+
+	return strings.Join(ps, w.Sep)
+}
+
+// describeTime returns description of second, minute and hour
+func (r Rule) describeTime(w Wording) []string {
+	sk, sn := kindOf(r.Second, r.steps[Second])
+	mk, mn := kindOf(r.Minute, r.steps[Minute])
+	hk, hn := kindOf(r.Hour, r.steps[Hour])
+
+	if sk == oneValue && mk == oneValue && (hk == oneValue || hk == listValue) {
+		ts := make([]string, len(r.Hour))
+		for i, h := range r.Hour {
+			ts[i] = clock(h, r.Minute[0], r.Second[0])
+		}
+		return []string{fmt.Sprintf(w.At, w.join(ts, w.And))}
+	}
+
+	ps := r.describeMinute(w, r.describeSecond(w, sk, sn), mk, mn, hk)
+
+	var hour string
+	switch hk {
+	case oneValue, rangeValue:
+		hour = fmt.Sprintf(w.Between, clock(r.Hour[0], 0, 0), clock(r.Hour[len(r.Hour)-1], 59, 0))
+	case stepValue:
+		ps = append(ps, fmt.Sprintf(w.EveryHours, hn))
+	case listValue:
+		hour = fmt.Sprintf(w.InHours, w.format(r.Hour, hk, func(v int) string { return clock(v, 0, 0) }))
+	}
+
+	if hour != "" {
+		if len(ps) == 0 {
+			return []string{hour}
+		}
+		ps[len(ps)-1] += " " + hour
+	}
+
+	return ps
+}
+
+// describeSecond returns description of second, nothing for second 0
+func (r Rule) describeSecond(w Wording, k values, n int) []string {
+	switch {
+	case k == anyValue:
+		return []string{w.EverySecond}
+	case k == stepValue:
+		return []string{fmt.Sprintf(w.EverySeconds, n)}
+	case k != oneValue || r.Second[0] != 0:
+		return []string{fmt.Sprintf(w.SecondsPast, w.format(r.Second, k, strconv.Itoa))}
+	default:
+		return nil
+	}
+}
+
+// describeMinute returns description of minute appended to description of second
+func (r Rule) describeMinute(w Wording, ps []string, k values, n int, hk values) []string {
+	switch {
+	case k == anyValue && len(ps) == 0:
+		return []string{w.EveryMinute}
+	case k == anyValue:
+		return ps
+	case k == stepValue:
+		return append(ps, fmt.Sprintf(w.EveryMinutes, n))
+	case k == oneValue && r.Minute[0] == 0 && len(ps) == 0:
+		if hk == stepValue {
+			return ps
+		}
+		return []string{w.EveryHour}
+	default:
+		return append(ps, fmt.Sprintf(w.MinutesPast, w.format(r.Minute, k, strconv.Itoa)))
+	}
+}
+
+// describeDate returns description of day of month, day of week and month
+func (r Rule) describeDate(w Wording) []string {
+	var ps []string
+
+	var days []string
+	if dk, dn := kindOf(r.DayOfMonth, r.steps[DayOfMonth]); dk == stepValue {
+		days = append(days, fmt.Sprintf(w.EveryDays, dn))
+	} else if dk != anyValue {
+		days = append(days, fmt.Sprintf(w.OnDays, w.format(r.DayOfMonth, dk, strconv.Itoa)))
+	}
+
+	for _, n := range r.LastDayOfMonth {
+		if n == 0 {
+			days = append(days, w.LastDay)
+		} else {
+			days = append(days, fmt.Sprintf(w.DaysBeforeLast, n))
+		}
+	}
+
+	for _, n := range r.NearestWeekday {
+		if n == 0 {
+			days = append(days, w.LastWeekday)
+		} else {
+			days = append(days, fmt.Sprintf(w.NearestWeekday, n))
+		}
+	}
+
+	if len(days) > 0 {
+		ps = append(ps, w.join(days, w.Or))
+	}
+
+	var weekdays []string
+	if wk, _ := kindOf(r.DayOfWeek, r.steps[DayOfWeek]); wk == rangeValue {
+		weekdays = append(weekdays, w.format(r.DayOfWeek, wk, func(v int) string { return w.Weekdays[v] }))
+	} else if wk != anyValue {
+		names := make([]string, len(r.DayOfWeek))
+		for i, v := range r.DayOfWeek {
+			names[i] = w.Weekdays[v]
+		}
+		weekdays = append(weekdays, fmt.Sprintf(w.OnWeekdays, w.join(names, w.And)))
+	}
+
+	for _, v := range r.NthDayOfWeek {
+		nth := w.Last
+		if v.Nth > 0 {
+			nth = w.Ordinals[v.Nth-1]
+		}
+		weekdays = append(weekdays, fmt.Sprintf(w.NthWeekday, nth, w.Weekdays[v.Weekday]))
+	}
+
+	if len(weekdays) > 0 {
+		ps = append(ps, w.join(weekdays, w.Or))
+	}
+
+	switch mk, mn := kindOf(r.Month, r.steps[Month]); mk {
+	case anyValue:
+	case stepValue:
+		ps = append(ps, fmt.Sprintf(w.EveryMonths, mn))
+	case rangeValue:
+		ps = append(ps, w.format(r.Month, mk, func(v int) string { return w.Months[v-1] }))
+	default:
+		ps = append(ps, fmt.Sprintf(w.InMonths, w.format(r.Month, mk, func(v int) string { return w.Months[v-1] })))
+	}
+
+	return ps
+}
+
+// format returns values as range or list
+func (w Wording) format(vs []int, k values, f func(int) string) string {
+	if k == rangeValue {
+		return fmt.Sprintf(w.Through, f(vs[0]), f(vs[len(vs)-1]))
+	}
+
+	ss := make([]string, len(vs))
+	for i, v := range vs {
+		ss[i] = f(v)
+	}
+
+	return w.join(ss, w.And)
+}
+
+// join returns list of items, the last two are joined by last
+func (w Wording) join(ss []string, last string) string {
+	if len(ss) == 1 {
+		return ss[0]
+	}
+
+	return fmt.Sprintf(last, strings.Join(ss[:len(ss)-1], w.Sep), ss[len(ss)-1])
+}
+
+// kindOf returns kind of field values, and the step if the field is parsed from */n, */1 is any value
+func kindOf(vs []int, step int) (values, int) {
+	switch {
+	case len(vs) == 0, step == 1:
+		return anyValue, 0
+	case step > 0:
+		return stepValue, step
+	case len(vs) == 1:
+		return oneValue, 0
+	}
+
+	for i := 1; i < len(vs); i++ {
+		if vs[i]-vs[i-1] != 1 {
+			return listValue, 0
+		}
+	}
+
+	if len(vs) > 2 {
+		return rangeValue, 0
+	}
+
+	return listValue, 0
+}
+
+// clock returns time of hour, minute and second, the second is omitted if it is 0
+func clock(h, m, s int) string {
+	if s == 0 {
+		return fmt.Sprintf("%02d:%02d", h, m)
+	}
+
+	return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xcron
+
+import (
+	"testing"
+
+	"github.com/likexian/gokit/assert"
+)
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{"0 */15 9-17 * * mon-fri", "every 15 minutes between 09:00 and 17:59, Monday through Friday"},
+		{"* * * * * *", "every second"},
+		{"* * * * *", "every minute"},
+		{"*/20 * * * * *", "every 20 seconds"},
+		{"*/1 * * * * *", "every second"},
+		{"*/1 * * * *", "every minute"},
+		{"0 */1 * * *", "every hour"},
+		{"30 * * * * *", "at 30 seconds past the minute"},
+		{"0-10 */5 * * * *", "at 0 through 10 seconds past the minute, every 5 minutes"},
+		{"30 * * * *", "at 30 minutes past the hour"},
+		{"5,35 * * * *", "at 5 and 35 minutes past the hour"},
+		{"0 */6 * * *", "every 6 hours"},
+		{"0 9-17 * * *", "every hour between 09:00 and 17:59"},
+		{"* 9 * * *", "every minute between 09:00 and 09:59"},
+		{"*/15 9,12 * * *", "every 15 minutes in hours 09:00 and 12:00"},
+		{"30 9,12,15 * * *", "at 09:30, 12:30 and 15:30"},
+		{"15 30 9 * * *", "at 09:30:15"},
+		{"@hourly", "every hour"},
+		{"@daily", "at 00:00"},
+		{"@weekly", "at 00:00, only on Sunday"},
+		{"@monthly", "at 00:00, on day 1 of the month"},
+		{"@yearly", "at 00:00, on day 1 of the month, only in January"},
+		{"0 0 1,15 * *", "at 00:00, on day 1 and 15 of the month"},
+		{"0 0 1-7 * *", "at 00:00, on day 1 through 7 of the month"},
+		{"0 0 */10 * *", "at 00:00, every 10 days"},
+		{"0 0 */1 */1 *", "at 00:00"},
+		{"0 0 L * *", "at 00:00, on the last day of the month"},
+		{"0 0 L-3 * *", "at 00:00, 3 days before the last day of the month"},
+		{"0 0 15W * *", "at 00:00, on the weekday nearest day 15 of the month"},
+		{"0 0 1,LW * *", "at 00:00, on day 1 of the month or on the last weekday of the month"},
+		{"0 0 * * fri#3", "at 00:00, on the third Friday of the month"},
+		{"0 0 * * 5L", "at 00:00, on the last Friday of the month"},
+		{"0 12 * * sat,sun", "at 12:00, only on Saturday and Sunday"},
+		{"0 0 1 jan-mar *", "at 00:00, on day 1 of the month, January through March"},
+		{"0 0 1 1,4,7,10 *", "at 00:00, on day 1 of the month, only in January, April, July and October"},
+		{"0 0 1 jan,jul *", "at 00:00, on day 1 of the month, only in January and July"},
+		{"0 0 1 */6 *", "at 00:00, on day 1 of the month, every 6 months"},
+		{"0 0,6,12,18 * * *", "at 00:00, 06:00, 12:00 and 18:00"},
+		{"@every 90s", "every 1m30s"},
+		{"CRON_TZ=Asia/Shanghai 0 9 * * *", "at 09:00, in Asia/Shanghai time"},
+	}
+
+	for _, v := range tests {
+		assert.Equal(t, MustParse(v.in).Describe(), v.out, v.in)
+	}
+}
+
+func TestDescribeIn(t *testing.T) {
+	w := English
+	w.Weekdays = [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"}
+	w.Sep = ", "
+	w.And = "%s et %s"
+	w.Through = "du %s au %s"
+	w.EveryMinutes = "toutes les %d minutes"
+	w.Between = "entre %s et %s"
+	w.At = "à %s"
+	w.OnWeekdays = "uniquement le %s"
+
+	assert.Equal(t, MustParse("0 */15 9-17 * * mon-fri").DescribeIn(w),
+		"toutes les 15 minutes entre 09:00 et 17:59, du lundi au vendredi")
+	assert.Equal(t, MustParse("0 9 * * sat,sun").DescribeIn(w), "à 09:00, uniquement le samedi et dimanche")
+}
@@ -0,0 +1,381 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xcron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"time"
+)
+
+// Policy is concurrency policy of job when it is due and the previous run is still running
+type Policy int
+
+// Concurrency policy
+const (
+	// Allow start the run concurrently
+	Allow Policy = iota
+	// Skip skip the run, it is the default
+	Skip
+	// Queue start the run after the previous runs finished, runs over the limit set by WithQueue are skipped
+	Queue
+	// Replace cancel the previous runs by their context and start the run
+	Replace
+)
+
+// EventType is type of job event
+type EventType int
+
+// Event type
+const (
+	// Skipped is the run skipped since the previous run is still running
+	Skipped EventType = iota + 1
+	// Failed is the run returned error
+	Failed
+	// Panicked is the run panicked, the error is *PanicError
+	Panicked
+	// Missed is the run missed after downtime or clock jumps and ignored by misfire policy
+	Missed
+	// Locked is the run skipped since lock of job is held by others
+	Locked
+)
+
+// Event is event of job
+type Event struct {
+	// Type is type of event
+	Type EventType
+	// ID is id of job
+	ID string
+	// Time is the scheduled time of run, or the start time of run for Failed and Panicked
+	Time time.Time
+	// Err is error of run for Failed and Panicked
+	Err error
+}
+
+// Run is a finished run of job
+type Run struct {
+	// Start is the start time of run
+	Start time.Time
+	// Duration is how long the run takes
+	Duration time.Duration
+	// Err is error returned by run, or *PanicError if panicked
+	Err error
+}
+
+// JobInfo is state of job
+type JobInfo struct {
+	// ID is id of job
+	ID string
+	// Rule is rule string of job
+	Rule string
+	// Next is the next fire time, zero if it never fires
+	Next time.Time
+	// Last is the last finished run, zero if it never runs
+	Last Run
+	// Runs is number of finished runs
+	Runs int
+	// Failures is number of runs failed or panicked
+	Failures int
+	// Running is number of running runs
+	Running int
+}
+
+// PanicError is error of a panicked run
+type PanicError struct {
+	// Value is value passed to panic
+	Value interface{}
+	// Stack is stack trace of the panic
+	Stack []byte
+}
+
+// Error returns error message
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("xcron: job panicked: %v", e.Value)
+}
+
+// String returns name of policy
+func (p Policy) String() string {
+	switch p {
+	case Allow:
+		return "allow"
+	case Skip:
+		return "skip"
+	case Queue:
+		return "queue"
+	case Replace:
+		return "replace"
+	default:
+		return "unknown"
+	}
+}
+
+// String returns name of event type
+func (t EventType) String() string {
+	switch t {
+	case Skipped:
+		return "skipped"
+	case Failed:
+		return "failed"
+	case Panicked:
+		return "panicked"
+	case Missed:
+		return "missed"
+	case Locked:
+		return "locked"
+	default:
+		return "unknown"
+	}
+}
+
+// WithPolicy set concurrency policy of job, default is Skip
+func WithPolicy(p Policy) Option {
+	return func(j *Job) {
+		j.policy = p
+	}
+}
+
+// WithQueue set max number of runs waiting by Queue policy, default is 1, runs over it are skipped
+func WithQueue(n int) Option {
+	return func(j *Job) {
+		j.maxQueue = n
+	}
+}
+
+// WithTimeout set max duration of a run, context of run is canceled after it
+func WithTimeout(d time.Duration) Option {
+	return func(j *Job) {
+		j.timeout = d
+	}
+}
+
+// WithErrorHandler set the func called after a run of job failed or panicked,
+// it is called in addition to the event func of service
+func WithErrorHandler(f func(e Event)) Option {
+	return func(j *Job) {
+		j.onError = f
+	}
+}
+
+// WithHistory set max number of recent runs kept in history, default is 10, 0 for no history
+func WithHistory(n int) Option {
+	return func(j *Job) {
+		j.maxHistory = n
+	}
+}
+
+// fire start a run of job scheduled at the time by its concurrency policy
+func (s *Service) fire(j *Job, t time.Time) {
+	j.mu.Lock()
+	if j.stopped {
+		j.mu.Unlock()
+		return
+	}
+
+	if j.running > 0 {
+		switch j.policy {
+		case Skip:
+			j.mu.Unlock()
+			s.emit(Event{Type: Skipped, ID: j.id, Time: t})
+			return
+		case Queue:
+			if len(j.pending) >= j.maxQueue {
+				j.mu.Unlock()
+				s.emit(Event{Type: Skipped, ID: j.id, Time: t})
+				return
+			}
+			j.pending = append(j.pending, t)
+			j.mu.Unlock()
+			return
+		case Replace:
+			for _, cancel := range j.cancels {
+				cancel()
+			}
+		}
+	}
+
+	s.start(j, t)
+	j.mu.Unlock()
+}
+
+// emit call the event func of service
+func (s *Service) emit(e Event) {
+	s.RLock()
+	f := s.onEvent
+	s.RUnlock()
+
+	if f != nil {
+		f(e)
+	}
+}
+
+// start run the job scheduled at the time in a new goroutine, the lock of job must be held
+func (s *Service) start(j *Job, t time.Time) {
+	ctx, cancel := context.WithCancel(j.ctx)
+	id := j.seq
+	j.seq++
+	j.cancels[id] = cancel
+	j.running++
+	j.runs.Add(1)
+	done := s.track()
+
+	go func() {
+		defer done()
+		defer j.runs.Done()
+
+		s.run(ctx, j, t)
+
+		j.mu.Lock()
+		defer j.mu.Unlock()
+
+		cancel()
+		delete(j.cancels, id)
+		j.running--
+		if len(j.pending) > 0 && !j.stopped {
+			next := j.pending[0]
+			j.pending = j.pending[1:]
+			s.start(j, next)
+		}
+	}()
+}
+
+// run run the job scheduled at the time with its lock held, the run is recorded and error is reported
+func (s *Service) run(ctx context.Context, j *Job, t time.Time) {
+	start := s.clock.Now()
+	if j.locker != nil {
+		unlock, err := j.locker.Lock(j.id + "@" + strconv.FormatInt(t.UnixNano(), 10))
+		if errors.Is(err, ErrLocked) {
+			s.emit(Event{Type: Locked, ID: j.id, Time: t})
+			return
+		}
+		if err != nil {
+			s.report(j, Event{Type: Failed, ID: j.id, Time: start, Err: err})
+			return
+		}
+		defer s.hold(j, t, unlock)
+	}
+
+	err := j.call(ctx)
+	j.record(Run{Start: start, Duration: s.clock.Now().Sub(start), Err: err})
+	if err != nil {
+		e := Event{Type: Failed, ID: j.id, Time: start, Err: err}
+		var pe *PanicError
+		if errors.As(err, &pe) {
+			e.Type = Panicked
+		}
+		s.report(j, e)
+	}
+}
+
+// hold release the lock of run scheduled at the time after the next scheduled time of job,
+// so processes start the same run later still find it held, it is released when job is deleted
+func (s *Service) hold(j *Job, t time.Time, unlock func()) {
+	next := j.rules.Next(t)
+	d := next.Sub(s.clock.Now())
+	if next.IsZero() || d <= 0 {
+		unlock()
+		return
+	}
+
+	timer := s.clock.NewTimer(d)
+	go func() {
+		select {
+		case <-timer.C():
+		case <-j.ctx.Done():
+		}
+		unlock()
+		timer.Stop()
+	}()
+}
+
+// report emit the error event and call error handler of job
+func (s *Service) report(j *Job, e Event) {
+	s.emit(e)
+	if j.onError != nil {
+		j.onError(e)
+	}
+}
+
+// call run loop func of job with timeout, panic is recovered as *PanicError
+func (j *Job) call(ctx context.Context) (err error) {
+	if j.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+
+	return j.loop(ctx)
+}
+
+// record add finished run to history of job
+func (j *Job) record(r Run) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.last = r
+	j.runCount++
+	if r.Err != nil {
+		j.failCount++
+	}
+
+	if j.maxHistory <= 0 {
+		return
+	}
+
+	if len(j.history) >= j.maxHistory {
+		copy(j.history, j.history[len(j.history)-j.maxHistory+1:])
+		j.history = j.history[:j.maxHistory-1]
+	}
+
+	j.history = append(j.history, r)
+}
+
+// info returns state of job, next fire time is not set
+func (j *Job) info() JobInfo {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return JobInfo{
+		ID:       j.id,
+		Rule:     j.rule,
+		Last:     j.last,
+		Runs:     j.runCount,
+		Failures: j.failCount,
+		Running:  j.running,
+	}
+}
+
+// stop stop the job, queued runs are dropped and context of running runs is canceled
+func (j *Job) stop() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.stopped = true
+	j.pending = nil
+	j.cancel()
+}
@@ -0,0 +1,365 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xcron
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/likexian/gokit/assert"
+)
+
+// never is rule never fires, jobs of it are fired by hand in tests
+const never = "0 0 30 2 *"
+
+// counter is counting running and max running of job
+type counter struct {
+	runs    int32
+	running int32
+	max     int32
+}
+
+// enter count a run started
+func (c *counter) enter() {
+	atomic.AddInt32(&c.runs, 1)
+	n := atomic.AddInt32(&c.running, 1)
+	for {
+		m := atomic.LoadInt32(&c.max)
+		if n <= m || atomic.CompareAndSwapInt32(&c.max, m, n) {
+			return
+		}
+	}
+}
+
+// exit count a run finished
+func (c *counter) exit() {
+	atomic.AddInt32(&c.running, -1)
+}
+
+func TestPolicy(t *testing.T) {
+	tests := []struct {
+		policy Policy
+		runs   int32
+		max    int32
+		skip   int32
+	}{
+		{Allow, 3, 3, 0},
+		{Skip, 1, 1, 2},
+		{Queue, 2, 1, 1},
+		{Replace, 3, 3, 0},
+	}
+
+	for _, v := range tests {
+		t.Run(v.policy.String(), func(t *testing.T) {
+			c := New()
+
+			var skipped int32
+			c.OnEvent(func(e Event) {
+				if e.Type == Skipped {
+					assert.Equal(t, e.ID, "x")
+					atomic.AddInt32(&skipped, 1)
+				}
+			})
+
+			n := &counter{}
+			release := make(chan bool)
+			err := c.SetWithOptions("x", never, func() {
+				n.enter()
+				defer n.exit()
+				<-release
+			}, WithPolicy(v.policy))
+			assert.Nil(t, err)
+
+			j := c.jobs["x"]
+			for i := 0; i < 3; i++ {
+				c.fire(j, time.Now())
+			}
+
+			// wait for the running ones blocked
+			for atomic.LoadInt32(&n.running) != v.max {
+				time.Sleep(time.Millisecond)
+			}
+
+			close(release)
+			for atomic.LoadInt32(&n.runs) != v.runs || atomic.LoadInt32(&n.running) != 0 {
+				time.Sleep(time.Millisecond)
+			}
+
+			c.Empty()
+			c.Wait()
+
+			assert.Equal(t, atomic.LoadInt32(&n.runs), v.runs)
+			assert.Equal(t, atomic.LoadInt32(&n.max), v.max)
+			assert.Equal(t, atomic.LoadInt32(&skipped), v.skip)
+		})
+	}
+}
+
+func TestPolicyReplace(t *testing.T) {
+	c := New()
+
+	var mu sync.Mutex
+	canceled := 0
+	started := make(chan bool, 3)
+	err := c.SetFunc("x", never, func(ctx context.Context) error {
+		started <- true
+		<-ctx.Done()
+		mu.Lock()
+		canceled++
+		mu.Unlock()
+		return ctx.Err()
+	}, WithPolicy(Replace))
+	assert.Nil(t, err)
+
+	j := c.jobs["x"]
+	for i := 0; i < 3; i++ {
+		c.fire(j, time.Now())
+		<-started
+	}
+
+	// the last run is canceled by Del
+	c.Del("x")
+	c.Wait()
+
+	assert.Equal(t, canceled, 3)
+}
+
+func TestPolicyQueueLimit(t *testing.T) {
+	c := New()
+
+	var skipped int32
+	c.OnEvent(func(e Event) {
+		if e.Type == Skipped {
+			atomic.AddInt32(&skipped, 1)
+		}
+	})
+
+	var runs int32
+	release := make(chan bool)
+	err := c.SetWithOptions("x", never, func() {
+		atomic.AddInt32(&runs, 1)
+		<-release
+	}, WithPolicy(Queue), WithQueue(2))
+	assert.Nil(t, err)
+
+	j := c.jobs["x"]
+	for i := 0; i < 5; i++ {
+		c.fire(j, time.Now())
+	}
+	assert.Equal(t, atomic.LoadInt32(&skipped), int32(2))
+
+	close(release)
+	for atomic.LoadInt32(&runs) != 3 {
+		time.Sleep(time.Millisecond)
+	}
+
+	c.Empty()
+	c.Wait()
+
+	assert.Equal(t, atomic.LoadInt32(&runs), int32(3))
+}
+
+func TestPolicyQueueDel(t *testing.T) {
+	c := New()
+
+	var runs int32
+	release := make(chan bool)
+	err := c.SetWithOptions("x", never, func() {
+		atomic.AddInt32(&runs, 1)
+		<-release
+	}, WithPolicy(Queue))
+	assert.Nil(t, err)
+
+	j := c.jobs["x"]
+	for i := 0; i < 3; i++ {
+		c.fire(j, time.Now())
+	}
+
+	// queued runs are dropped after job deleted
+	c.Del("x")
+	close(release)
+	c.Wait()
+
+	assert.Equal(t, atomic.LoadInt32(&runs), int32(1))
+
+	// fire after deleted does nothing
+	c.fire(j, time.Now())
+	assert.Equal(t, atomic.LoadInt32(&runs), int32(1))
+}
+
+func TestAddFunc(t *testing.T) {
+	c := New()
+
+	events := make(chan Event, 10)
+	c.OnEvent(func(e Event) { events <- e })
+
+	handled := make(chan Event, 10)
+	id, err := c.AddFunc(never, func(ctx context.Context) error {
+		return errors.New("failed")
+	}, WithErrorHandler(func(e Event) { handled <- e }))
+	assert.Nil(t, err)
+	assert.True(t, c.Has(id))
+
+	c.fire(c.jobs[id], time.Now())
+	e := <-events
+	assert.Equal(t, e.Type, Failed)
+	assert.Equal(t, e.ID, id)
+	assert.Equal(t, e.Err.Error(), "failed")
+	assert.Equal(t, (<-handled).Err, e.Err)
+
+	// panic is recovered
+	err = c.SetFunc(id, never, func(ctx context.Context) error {
+		panic("oops")
+	})
+	assert.Nil(t, err)
+
+	c.fire(c.jobs[id], time.Now())
+	e = <-events
+	assert.Equal(t, e.Type, Panicked)
+	assert.Equal(t, e.Type.String(), "panicked")
+	var pe *PanicError
+	assert.True(t, errors.As(e.Err, &pe))
+	assert.Equal(t, pe.Value, "oops")
+	assert.Contains(t, string(pe.Stack), "xcron")
+	assert.Contains(t, pe.Error(), "oops")
+
+	// panic of plain func is recovered too
+	err = c.Set(id, never, func() { panic("oops") })
+	assert.Nil(t, err)
+	c.fire(c.jobs[id], time.Now())
+	assert.Equal(t, (<-events).Type, Panicked)
+
+	// success run reports nothing
+	err = c.SetFunc(id, never, func(ctx context.Context) error { return nil })
+	assert.Nil(t, err)
+	c.fire(c.jobs[id], time.Now())
+
+	c.Empty()
+	c.Wait()
+	assert.Equal(t, len(events), 0)
+}
+
+func TestTimeout(t *testing.T) {
+	c := New()
+
+	events := make(chan Event, 10)
+	c.OnEvent(func(e Event) { events <- e })
+
+	id, err := c.AddFunc(never, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithTimeout(50*time.Millisecond))
+	assert.Nil(t, err)
+
+	c.fire(c.jobs[id], time.Now())
+	e := <-events
+	assert.Equal(t, e.Type, Failed)
+	assert.True(t, errors.Is(e.Err, context.DeadlineExceeded))
+
+	c.Empty()
+	c.Wait()
+}
+
+func TestEmptyCancel(t *testing.T) {
+	c := New()
+
+	started := make(chan bool, 3)
+	var canceled int32
+	for i := 0; i < 3; i++ {
+		id, err := c.AddFunc(never, func(ctx context.Context) error {
+			started <- true
+			<-ctx.Done()
+			atomic.AddInt32(&canceled, 1)
+			return nil
+		})
+		assert.Nil(t, err)
+		c.fire(c.jobs[id], time.Now())
+	}
+
+	for i := 0; i < 3; i++ {
+		<-started
+	}
+
+	c.Empty()
+	c.Wait()
+	assert.Equal(t, atomic.LoadInt32(&canceled), int32(3))
+}
+
+func TestJobs(t *testing.T) {
+	c := New()
+
+	n := 0
+	err := c.SetFunc("a", never, func(ctx context.Context) error {
+		n++
+		if n%2 == 0 {
+			return errors.New("failed")
+		}
+		return nil
+	}, WithHistory(3))
+	assert.Nil(t, err)
+
+	err = c.Set("b", "@hourly", func() {})
+	assert.Nil(t, err)
+
+	js := c.Jobs()
+	assert.Equal(t, len(js), 2)
+	assert.Equal(t, js[0].ID, "a")
+	assert.Equal(t, js[0].Rule, never)
+	assert.True(t, js[0].Next.IsZero())
+	assert.True(t, js[0].Last.Start.IsZero())
+	assert.Equal(t, js[1].ID, "b")
+	assert.True(t, js[1].Next.After(time.Now()))
+	assert.Equal(t, js[1].Next.Minute(), 0)
+
+	for i := 0; i < 5; i++ {
+		c.fire(c.jobs["a"], time.Now())
+		for {
+			if v, _ := c.Job("a"); v.Runs == i+1 && v.Running == 0 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	v, ok := c.Job("a")
+	assert.True(t, ok)
+	assert.Equal(t, v.Runs, 5)
+	assert.Equal(t, v.Failures, 2)
+	assert.Equal(t, v.Running, 0)
+	assert.Nil(t, v.Last.Err)
+
+	h := c.History("a")
+	assert.Equal(t, len(h), 3)
+	assert.Nil(t, h[0].Err)
+	assert.NotNil(t, h[1].Err)
+	assert.Nil(t, h[2].Err)
+	assert.True(t, h[0].Start.Before(h[2].Start))
+
+	_, ok = c.Job("x")
+	assert.False(t, ok)
+	assert.Equal(t, len(c.History("x")), 0)
+
+	c.Empty()
+	c.Wait()
+}
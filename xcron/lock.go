@@ -0,0 +1,119 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xcron
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// ErrLocked is lock is held by others
+	ErrLocked = errors.New("xcron: lock is held")
+	// ErrLockID is job with lock is added without a stable id
+	ErrLockID = errors.New("xcron: lock requires job set by a stable id")
+)
+
+// Locker is lock of job across processes, a run of job is skipped if its lock is held by others
+type Locker interface {
+	// Lock acquire lock of name without blocking, returns ErrLocked if it is held by others,
+	// the returned func releases the lock
+	Lock(name string) (unlock func(), err error)
+}
+
+// FileLocker is Locker by flock of files, for processes on the same host,
+// the lock is in-process only on platforms without flock
+type FileLocker struct {
+	dir string
+}
+
+// NewFileLocker returns new file locker, lock files are created in the dir
+func NewFileLocker(dir string) *FileLocker {
+	return &FileLocker{dir: dir}
+}
+
+// WithLock set locker of job, the lock named by job id and scheduled time is held from the run
+// until the next scheduled time, so every scheduled run of the job runs in only one process.
+// Job with lock must be set by Set or SetFunc with an id the same across processes, Add returns ErrLockID
+func WithLock(l Locker) Option {
+	return func(j *Job) {
+		j.locker = l
+	}
+}
+
+// Lock acquire lock of name by flock of file, returns ErrLocked if it is held by others,
+// the file is removed when unlock
+func (l *FileLocker) Lock(name string) (func(), error) {
+	err := os.MkdirAll(l.dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	fpath := filepath.Join(l.dir, url.PathEscape(name)+".lock")
+	for {
+		fd, err := os.OpenFile(fpath, os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			return nil, err
+		}
+
+		ok, err := lockFile(fd)
+		if err != nil {
+			fd.Close()
+			return nil, err
+		}
+
+		if ok {
+			return func() {
+				_ = os.Remove(fd.Name())
+				_ = unlockFile(fd)
+				fd.Close()
+			}, nil
+		}
+
+		fd.Close()
+	}
+}
+
+// lockFile lock the opened file, returns false if the file is removed or replaced by the holder
+// before locked, since the locked file is not the file of its path any more
+func lockFile(fd *os.File) (bool, error) {
+	err := tryLockFile(fd)
+	if err != nil {
+		return false, err
+	}
+
+	fi, err := fd.Stat()
+	if err == nil {
+		var pi os.FileInfo
+		pi, err = os.Stat(fd.Name())
+		if err == nil && os.SameFile(fi, pi) {
+			return true, nil
+		}
+	}
+
+	_ = unlockFile(fd)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	return false, nil
+}
@@ -0,0 +1,50 @@
+//go:build !unix
+
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xcron
+
+import (
+	"os"
+	"sync"
+)
+
+// locks is mutex of lock files, the lock is in-process only on platforms without flock
+var locks sync.Map
+
+// tryLockFile lock the file by in-process mutex without blocking, returns ErrLocked if it is held
+func tryLockFile(fd *os.File) error {
+	m, _ := locks.LoadOrStore(fd.Name(), &sync.Mutex{})
+	if !m.(*sync.Mutex).TryLock() {
+		return ErrLocked
+	}
+
+	return nil
+}
+
+// unlockFile unlock the file locked by tryLockFile
+func unlockFile(fd *os.File) error {
+	m, ok := locks.Load(fd.Name())
+	if ok {
+		m.(*sync.Mutex).Unlock()
+	}
+
+	return nil
+}
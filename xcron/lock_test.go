@@ -0,0 +1,218 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xcron
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/likexian/gokit/assert"
+)
+
+func TestFileLocker(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "lock")
+	l := NewFileLocker(dir)
+
+	unlock, err := l.Lock("a/b")
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "a%2Fb.lock"))
+	assert.Nil(t, err)
+
+	_, err = NewFileLocker(dir).Lock("a/b")
+	assert.Equal(t, err, ErrLocked)
+
+	unlockc, err := l.Lock("c")
+	assert.Nil(t, err)
+	unlockc()
+	_, err = os.Stat(filepath.Join(dir, "c.lock"))
+	assert.True(t, os.IsNotExist(err))
+
+	unlock()
+	unlock, err = l.Lock("a/b")
+	assert.Nil(t, err)
+	unlock()
+
+	err = os.WriteFile(filepath.Join(dir, "c"), nil, 0644)
+	assert.Nil(t, err)
+	_, err = NewFileLocker(filepath.Join(dir, "c")).Lock("x")
+	assert.NotNil(t, err)
+}
+
+func TestFileLockerRemoved(t *testing.T) {
+	dir := t.TempDir()
+	fpath := filepath.Join(dir, "x.lock")
+
+	unlock, err := NewFileLocker(dir).Lock("x")
+	assert.Nil(t, err)
+
+	// file opened by others before the holder removed it
+	fd, err := os.OpenFile(fpath, os.O_RDWR, 0644)
+	assert.Nil(t, err)
+	defer fd.Close()
+
+	unlock()
+	ok, err := lockFile(fd)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// file replaced by new holder
+	unlock, err = NewFileLocker(dir).Lock("x")
+	assert.Nil(t, err)
+	ok, _ = lockFile(fd)
+	assert.False(t, ok)
+
+	_, err = NewFileLocker(dir).Lock("x")
+	assert.Equal(t, err, ErrLocked)
+	unlock()
+}
+
+// errLocker is locker always failed
+type errLocker struct{}
+
+// Lock returns error
+func (errLocker) Lock(string) (func(), error) {
+	return nil, errors.New("lock failed")
+}
+
+func TestWithLock(t *testing.T) {
+	l := NewFileLocker(t.TempDir())
+
+	events := make(chan Event, 10)
+	started := make(chan bool, 10)
+	release := make(chan bool)
+
+	cs := []*Service{New(), New()}
+	for _, c := range cs {
+		c.OnEvent(func(e Event) {
+			events <- e
+		})
+		err := c.SetWithOptions("x", never, func() {
+			started <- true
+			<-release
+		}, WithLock(l))
+		assert.Nil(t, err)
+	}
+
+	now := time.Now()
+	cs[0].fire(cs[0].jobs["x"], now)
+	<-started
+
+	cs[1].fire(cs[1].jobs["x"], now)
+	e := <-events
+	assert.Equal(t, e.Type, Locked)
+	assert.Equal(t, e.ID, "x")
+	assert.Equal(t, e.Time, now)
+
+	close(release)
+	for _, c := range cs {
+		c.Empty()
+		c.Wait()
+	}
+
+	assert.Equal(t, len(started), 0)
+
+	c := New()
+	c.OnEvent(func(e Event) {
+		events <- e
+	})
+	err := c.SetWithOptions("x", never, func() {}, WithLock(errLocker{}))
+	assert.Nil(t, err)
+
+	c.fire(c.jobs["x"], time.Now())
+	e = <-events
+	assert.Equal(t, e.Type, Failed)
+	assert.Equal(t, e.Err.Error(), "lock failed")
+
+	c.Empty()
+	c.Wait()
+
+	_, err = c.AddWithOptions(never, func() {}, WithLock(l))
+	assert.Equal(t, err, ErrLockID)
+	_, err = c.AddFunc(never, func(context.Context) error { return nil }, WithLock(l))
+	assert.Equal(t, err, ErrLockID)
+	assert.Equal(t, c.Len(), 0)
+}
+
+func TestWithLockHold(t *testing.T) {
+	l := NewFileLocker(t.TempDir())
+	clock := NewFakeClock(time.Date(2026, 1, 1, 0, 0, 30, 0, time.UTC))
+
+	var runs, locked int32
+	cs := []*Service{NewWithClock(clock), NewWithClock(clock)}
+	for _, c := range cs {
+		c.OnEvent(func(e Event) {
+			if e.Type == Locked {
+				atomic.AddInt32(&locked, 1)
+			}
+		})
+		err := c.SetWithOptions("x", "0 * * * * *", func() {
+			atomic.AddInt32(&runs, 1)
+		}, WithLock(l))
+		assert.Nil(t, err)
+	}
+
+	for i := 1; i <= 10; i++ {
+		clock.Advance(time.Minute)
+		assert.Equal(t, atomic.LoadInt32(&runs), int32(i))
+		assert.Equal(t, atomic.LoadInt32(&locked), int32(i))
+	}
+
+	for _, c := range cs {
+		c.Empty()
+		c.Wait()
+	}
+}
+
+func TestWithLockEvery(t *testing.T) {
+	l := NewFileLocker(t.TempDir())
+	clock := NewFakeClock(time.Date(2026, 1, 1, 0, 0, 30, 0, time.UTC))
+
+	var runs, locked int32
+	cs := []*Service{NewWithClock(clock), NewWithClock(clock)}
+	for _, c := range cs {
+		c.OnEvent(func(e Event) {
+			if e.Type == Locked {
+				atomic.AddInt32(&locked, 1)
+			}
+		})
+		err := c.SetWithOptions("x", "@every 1m", func() {
+			atomic.AddInt32(&runs, 1)
+		}, WithLock(l))
+		assert.Nil(t, err)
+		// services are started at different times
+		clock.Advance(13 * time.Second)
+	}
+
+	for i := 1; i <= 10; i++ {
+		clock.Advance(time.Minute)
+		assert.Equal(t, atomic.LoadInt32(&runs), int32(i))
+		assert.Equal(t, atomic.LoadInt32(&locked), int32(i))
+	}
+
+	for _, c := range cs {
+		c.Empty()
+		c.Wait()
+	}
+}
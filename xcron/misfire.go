@@ -0,0 +1,223 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xcron
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Misfire is policy of runs missed after downtime or clock jumps
+type Misfire int
+
+// Misfire policy
+const (
+	// MisfireIgnore ignore the missed runs, it is the default
+	MisfireIgnore Misfire = iota
+	// MisfireOnce run once to catch up the missed runs
+	MisfireOnce
+	// MisfireAll run every missed run one by one, up to the limit
+	MisfireAll
+)
+
+// misfireThreshold is how late a run is considered as missed
+const misfireThreshold = 5 * time.Second
+
+// dueRun is job due at the time
+type dueRun struct {
+	job   *Job
+	times []time.Time
+	late  bool
+}
+
+// WithMisfire set misfire policy of job, limit is max number of missed runs fired by MisfireAll,
+// the oldest runs are fired first and the rest are dropped, limit less than 1 is taken as 1
+func WithMisfire(p Misfire, limit int) Option {
+	return func(j *Job) {
+		j.misfire = p
+		j.misfireMax = limit
+	}
+}
+
+// String returns name of misfire policy
+func (m Misfire) String() string {
+	switch m {
+	case MisfireIgnore:
+		return "ignore"
+	case MisfireOnce:
+		return "once"
+	case MisfireAll:
+		return "all"
+	default:
+		return "unknown"
+	}
+}
+
+// SetState set the state file storing the last fire time of jobs with misfire policy, it is loaded
+// at once and saved after these jobs fired, so they catch up runs missed while the service is down,
+// id of job must be stable across restarts, use Set instead of Add. State of job is removed by Del,
+// and kept by Empty, so it is resumed after restart
+func (s *Service) SetState(fpath string) error {
+	state := map[string]time.Time{}
+
+	data, err := os.ReadFile(fpath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if len(data) > 0 {
+		err = json.Unmarshal(data, &state)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.fstate = fpath
+	s.state = state
+
+	now := s.clock.Now()
+	for _, j := range s.jobs {
+		s.resume(j, now)
+	}
+
+	s.notify()
+
+	return nil
+}
+
+// resume schedule job after its last fire time in state if any, otherwise after now,
+// the missed runs are handled by misfire policy once fired, the lock must be held
+func (s *Service) resume(j *Job, now time.Time) {
+	last, ok := s.state[j.id]
+	if ok && last.Before(now) {
+		s.schedule(j, last)
+		return
+	}
+
+	s.schedule(j, now)
+}
+
+// due returns fire times of job due before now, the lock must be held
+func (s *Service) due(j *Job, now time.Time) dueRun {
+	if s.state != nil && j.misfire != MisfireIgnore {
+		s.state[j.id] = now
+		s.dirty = true
+	}
+
+	r := dueRun{job: j, times: []time.Time{j.next}, late: now.Sub(j.next) > misfireThreshold}
+	if !r.late || j.misfire != MisfireAll {
+		return r
+	}
+
+	for t := j.rules.Next(j.next); len(r.times) < j.misfireMax; t = j.rules.Next(t) {
+		if t.IsZero() || t.After(now) {
+			break
+		}
+		r.times = append(r.times, t)
+	}
+
+	return r
+}
+
+// misfire fire the due job by its misfire policy
+func (s *Service) misfire(r dueRun) {
+	j := r.job
+	if !r.late {
+		s.fire(j, r.times[0])
+		return
+	}
+
+	switch j.misfire {
+	case MisfireOnce:
+		s.fire(j, r.times[0])
+	case MisfireAll:
+		s.catchUp(j, r.times)
+	default:
+		s.emit(Event{Type: Missed, ID: j.id, Time: r.times[0]})
+	}
+}
+
+// catchUp fire runs of job scheduled at the times one by one whatever the concurrency policy is
+func (s *Service) catchUp(j *Job, times []time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.stopped {
+		return
+	}
+
+	if j.running == 0 {
+		s.start(j, times[0])
+		times = times[1:]
+	}
+
+	j.pending = append(j.pending, times...)
+}
+
+// forget remove state of job, the lock must be held
+func (s *Service) forget(id string) {
+	if _, ok := s.state[id]; ok {
+		delete(s.state, id)
+		s.dirty = true
+	}
+}
+
+// saveState save the state to file if it is changed, it is written to a temp file and renamed
+func (s *Service) saveState() {
+	s.saving.Lock()
+	defer s.saving.Unlock()
+
+	s.Lock()
+	if s.fstate == "" || !s.dirty {
+		s.Unlock()
+		return
+	}
+
+	fpath := s.fstate
+	data, err := json.Marshal(s.state)
+	s.dirty = false
+	s.Unlock()
+	if err != nil {
+		return
+	}
+
+	fd, err := os.CreateTemp(filepath.Dir(fpath), ".xcron-*")
+	if err != nil {
+		return
+	}
+
+	_, err = fd.Write(data)
+	if e := fd.Close(); err == nil {
+		err = e
+	}
+
+	if err == nil {
+		err = os.Rename(fd.Name(), fpath)
+	}
+
+	if err != nil {
+		_ = os.Remove(fd.Name())
+	}
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xcron
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/likexian/gokit/assert"
+)
+
+func TestMisfire(t *testing.T) {
+	tests := []struct {
+		misfire Misfire
+		limit   int
+		runs    int32
+		missed  int32
+	}{
+		{MisfireIgnore, 0, 0, 1},
+		{MisfireOnce, 0, 1, 0},
+		{MisfireAll, 10, 3, 0},
+		{MisfireAll, 2, 2, 0},
+		{MisfireAll, 0, 1, 0},
+	}
+
+	for _, v := range tests {
+		t.Run(v.misfire.String(), func(t *testing.T) {
+			fpath := filepath.Join(t.TempDir(), "state.json")
+			clock := NewFakeClock(time.Date(2026, 1, 1, 10, 30, 0, 0, time.UTC))
+			// the last run is 3 hourly runs ago
+			last := time.Date(2026, 1, 1, 7, 59, 0, 0, time.UTC)
+			data, err := json.Marshal(map[string]time.Time{"x": last})
+			assert.Nil(t, err)
+			assert.Nil(t, os.WriteFile(fpath, data, 0o644))
+
+			c := NewWithClock(clock)
+			assert.Nil(t, c.SetState(fpath))
+
+			var missed int32
+			c.OnEvent(func(e Event) {
+				if e.Type == Missed {
+					assert.Equal(t, e.ID, "x")
+					assert.Equal(t, e.Time, time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC))
+					atomic.AddInt32(&missed, 1)
+				}
+			})
+
+			var runs int32
+			err = c.SetWithOptions("x", "0 * * * *", func() {
+				atomic.AddInt32(&runs, 1)
+			}, WithLocation(time.UTC), WithMisfire(v.misfire, v.limit))
+			assert.Nil(t, err)
+
+			clock.Advance(0)
+			assert.Equal(t, atomic.LoadInt32(&runs), v.runs)
+			assert.Equal(t, atomic.LoadInt32(&missed), v.missed)
+
+			c.Empty()
+			c.Wait()
+
+			state := map[string]time.Time{}
+			data, err = os.ReadFile(fpath)
+			assert.Nil(t, err)
+			assert.Nil(t, json.Unmarshal(data, &state))
+			if v.misfire == MisfireIgnore {
+				assert.Equal(t, state["x"], last)
+			} else {
+				assert.Equal(t, state["x"], clock.Now())
+			}
+		})
+	}
+}
+
+func TestMisfireState(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "state.json")
+	clock := NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	c := NewWithClock(clock)
+	assert.Nil(t, c.SetState(fpath))
+
+	var runs int32
+	err := c.SetWithOptions("x", "@every 1s", func() {
+		atomic.AddInt32(&runs, 1)
+	}, WithMisfire(MisfireOnce, 0))
+	assert.Nil(t, err)
+	err = c.Set("y", "@every 1s", func() {
+		atomic.AddInt32(&runs, 1)
+	})
+	assert.Nil(t, err)
+
+	clock.Advance(3 * time.Second)
+	assert.Equal(t, atomic.LoadInt32(&runs), int32(6))
+
+	// only jobs with misfire policy are saved
+	state := map[string]time.Time{}
+	data, err := os.ReadFile(fpath)
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal(data, &state))
+	assert.Equal(t, state, map[string]time.Time{"x": clock.Now()})
+
+	// state of deleted job is removed
+	c.Del("x")
+	data, err = os.ReadFile(fpath)
+	assert.Nil(t, err)
+	assert.Equal(t, string(data), "{}")
+
+	// state is not saved if jobs fired have no misfire policy
+	assert.Nil(t, os.Remove(fpath))
+	clock.Advance(3 * time.Second)
+	assert.Equal(t, atomic.LoadInt32(&runs), int32(9))
+	_, err = os.Stat(fpath)
+	assert.True(t, os.IsNotExist(err))
+
+	c.Empty()
+	c.Wait()
+
+	assert.Nil(t, os.WriteFile(fpath, []byte("x"), 0o644))
+	assert.NotNil(t, c.SetState(fpath))
+}
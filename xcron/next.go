@@ -0,0 +1,292 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xcron
+
+import (
+	"time"
+)
+
+// maxYears is max years to search for the fire time, rule without fire time in it never fires
+const maxYears = 400
+
+// Next returns the first fire time of rule strictly after the time, in location of rule if set,
+// otherwise in location of the time, zero time is returned if rule never fires, for example Feb 30.
+// Rule of @every duration fires at multiples of the duration since zero time,
+// so processes started at different times share the same fire times.
+// Wall clock skipped by daylight saving fires once at the transition, repeated wall clock fires once.
+func (r Rule) Next(after time.Time) time.Time {
+	after = after.In(r.location(after))
+	if r.Every > 0 {
+		return after.Truncate(r.Every).Add(r.Every)
+	}
+
+	w := wall(after)
+	for {
+		w = r.next(w)
+		if w.IsZero() {
+			return w
+		}
+		if t := instant(w, after.Location()); t.After(after) {
+			return t
+		}
+	}
+}
+
+// Prev returns the last fire time of rule strictly before the time, in location of rule if set,
+// otherwise in location of the time, zero time is returned if rule never fires
+func (r Rule) Prev(before time.Time) time.Time {
+	before = before.In(r.location(before))
+	if r.Every > 0 {
+		t := before.Truncate(r.Every)
+		if !t.Before(before) {
+			t = t.Add(-r.Every)
+		}
+		return t
+	}
+
+	w := wall(before)
+	for {
+		w = r.prev(w)
+		if w.IsZero() {
+			return w
+		}
+		if t := instant(w, before.Location()); t.Before(before) {
+			return t
+		}
+	}
+}
+
+// NextN returns at most n fire times of rule after the time,
+// it is less than n if rule stops firing, empty if rule never fires
+func (r Rule) NextN(after time.Time, n int) []time.Time {
+	ts := []time.Time{}
+	for len(ts) < n {
+		after = r.Next(after)
+		if after.IsZero() {
+			break
+		}
+		ts = append(ts, after)
+	}
+
+	return ts
+}
+
+// next returns the first wall clock matched by rule strictly after the wall clock in UTC
+func (r Rule) next(after time.Time) time.Time {
+	t := after.Truncate(time.Second).Add(time.Second)
+	limit := t.Year() + maxYears
+
+	for t.Year() <= limit {
+		y, m, d := t.Date()
+		h, i, s := t.Clock()
+		switch {
+		case !match(r.Month, int(m)):
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, time.UTC)
+		case !r.matchDay(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+		case !match(r.Hour, h):
+			t = time.Date(y, m, d, h+1, 0, 0, 0, time.UTC)
+		case !match(r.Minute, i):
+			t = time.Date(y, m, d, h, i+1, 0, 0, time.UTC)
+		case !match(r.Second, s):
+			t = t.Add(time.Second)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// prev returns the last wall clock matched by rule strictly before the wall clock in UTC
+func (r Rule) prev(before time.Time) time.Time {
+	t := before.Add(-1).Truncate(time.Second)
+	limit := t.Year() - maxYears
+
+	for t.Year() >= limit {
+		y, m, d := t.Date()
+		h, i, s := t.Clock()
+		switch {
+		case !match(r.Month, int(m)):
+			t = time.Date(y, m, 1, 0, 0, -1, 0, time.UTC)
+		case !r.matchDay(t):
+			t = time.Date(y, m, d, 0, 0, -1, 0, time.UTC)
+		case !match(r.Hour, h):
+			t = time.Date(y, m, d, h, 0, -1, 0, time.UTC)
+		case !match(r.Minute, i):
+			t = time.Date(y, m, d, h, i, -1, 0, time.UTC)
+		case !match(r.Second, s):
+			t = t.Add(-time.Second)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// location returns location of rule, or location of the time if not set
+func (r Rule) location(t time.Time) *time.Location {
+	if r.Location != nil {
+		return r.Location
+	}
+
+	return t.Location()
+}
+
+// matchDay returns day of the time is matched by both day of month and day of week
+func (r Rule) matchDay(t time.Time) bool {
+	return r.matchDayOfMonth(t) && r.matchDayOfWeek(t)
+}
+
+// matchDayOfMonth returns day of the time is matched by day of month, L and W
+func (r Rule) matchDayOfMonth(t time.Time) bool {
+	if len(r.DayOfMonth) == 0 && len(r.LastDayOfMonth) == 0 && len(r.NearestWeekday) == 0 {
+		return true
+	}
+
+	d := t.Day()
+	if contains(r.DayOfMonth, d) {
+		return true
+	}
+
+	last := lastDay(t)
+	for _, n := range r.LastDayOfMonth {
+		if d == last-n {
+			return true
+		}
+	}
+
+	for _, n := range r.NearestWeekday {
+		if n == 0 {
+			n = last
+		}
+		if n <= last && d == nearestWeekday(t, n, last) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchDayOfWeek returns day of the time is matched by day of week, # and L
+func (r Rule) matchDayOfWeek(t time.Time) bool {
+	if len(r.DayOfWeek) == 0 && len(r.NthDayOfWeek) == 0 {
+		return true
+	}
+
+	w := int(t.Weekday())
+	if contains(r.DayOfWeek, w) {
+		return true
+	}
+
+	d := t.Day()
+	for _, v := range r.NthDayOfWeek {
+		if v.Weekday != w {
+			continue
+		}
+		if (v.Nth > 0 && (d-1)/7+1 == v.Nth) || (v.Nth < 0 && d+7 > lastDay(t)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lastDay returns the last day of month of the time
+func lastDay(t time.Time) int {
+	y, m, _ := t.Date()
+	return time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// nearestWeekday returns the weekday nearest to day n in month of the time, not crossing the month
+func nearestWeekday(t time.Time, n, last int) int {
+	y, m, _ := t.Date()
+	switch time.Date(y, m, n, 0, 0, 0, 0, time.UTC).Weekday() {
+	case time.Saturday:
+		if n == 1 {
+			return n + 2
+		}
+		return n - 1
+	case time.Sunday:
+		if n == last {
+			return n - 2
+		}
+		return n + 1
+	default:
+		return n
+	}
+}
+
+// match returns v is in vs, empty vs matches all
+func match(vs []int, v int) bool {
+	return len(vs) == 0 || contains(vs, v)
+}
+
+// contains returns v is in vs
+func contains(vs []int, v int) bool {
+	for _, x := range vs {
+		if x == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// wall returns wall clock of the time as UTC time
+func wall(t time.Time) time.Time {
+	y, m, d := t.Date()
+	h, i, s := t.Clock()
+
+	return time.Date(y, m, d, h, i, s, t.Nanosecond(), time.UTC)
+}
+
+// instant returns the first time of wall clock w in location,
+// or the transition time if the wall clock is skipped by daylight saving
+func instant(w time.Time, loc *time.Location) time.Time {
+	y, m, d := w.Date()
+	h, i, s := w.Clock()
+	t := time.Date(y, m, d, h, i, s, 0, loc)
+
+	start, end := t.ZoneBounds()
+	if !wall(t).Equal(w) {
+		if w.Before(wall(t)) {
+			return start
+		}
+		return end
+	}
+
+	if start.IsZero() {
+		return t
+	}
+
+	// the wall clock may be repeated after offset turned back, use the first one
+	_, offset := t.Zone()
+	_, before := start.Add(-1).Zone()
+	if before > offset {
+		e := t.Add(-time.Duration(before-offset) * time.Second)
+		if e.Before(start) && wall(e).Equal(w) {
+			return e
+		}
+	}
+
+	return t
+}
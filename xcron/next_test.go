@@ -0,0 +1,235 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xcron
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/likexian/gokit/assert"
+)
+
+func TestNext(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04:05", s)
+		assert.Nil(t, err)
+		return v
+	}
+
+	tests := []struct {
+		rule  string
+		after string
+		out   string
+	}{
+		{"* * * * * *", "2019-04-10 00:00:00", "2019-04-10 00:00:01"},
+		{"* * * * *", "2019-04-10 00:00:00", "2019-04-10 00:01:00"},
+		{"0 * * * *", "2019-04-10 00:00:00", "2019-04-10 01:00:00"},
+		{"*/15 * * * *", "2019-04-10 00:14:59", "2019-04-10 00:15:00"},
+		{"30 9 * * *", "2019-04-10 09:30:00", "2019-04-11 09:30:00"},
+		{"30 9 * * *", "2019-04-10 09:29:59", "2019-04-10 09:30:00"},
+		{"0 0 1 * *", "2019-12-15 00:00:00", "2020-01-01 00:00:00"},
+		{"0 0 29 2 *", "2019-01-01 00:00:00", "2020-02-29 00:00:00"},
+		{"0 0 * * mon", "2019-04-10 00:00:00", "2019-04-15 00:00:00"},
+		{"0 0 13 * fri", "2019-04-10 00:00:00", "2019-09-13 00:00:00"},
+		{"0 0 31 * *", "2019-04-10 00:00:00", "2019-05-31 00:00:00"},
+		{"59 59 23 31 12 *", "2019-04-10 00:00:00", "2019-12-31 23:59:59"},
+		{"@yearly", "2019-04-10 00:00:00", "2020-01-01 00:00:00"},
+		{"@weekly", "2019-04-10 00:00:00", "2019-04-14 00:00:00"},
+		{"@every 6 hour", "2019-04-10 13:00:00", "2019-04-10 18:00:00"},
+	}
+
+	for _, v := range tests {
+		r := MustParse(v.rule)
+		assert.Equal(t, r.Next(at(v.after)), at(v.out), v)
+		assert.Equal(t, r.Prev(at(v.out)).Before(at(v.out)), true, v)
+		assert.Equal(t, r.Prev(at(v.out).Add(time.Second)), at(v.out), v)
+	}
+
+	// fractional second
+	r := MustParse("* * * * * *")
+	assert.Equal(t, r.Next(at("2019-04-10 00:00:00").Add(500*time.Millisecond)), at("2019-04-10 00:00:01"))
+	assert.Equal(t, r.Prev(at("2019-04-10 00:00:00").Add(500*time.Millisecond)), at("2019-04-10 00:00:00"))
+	assert.Equal(t, r.Prev(at("2019-04-10 00:00:00")), at("2019-04-09 23:59:59"))
+
+	// location is kept
+	loc := time.FixedZone("UTC+8", 8*3600)
+	n := MustParse("0 9 * * *").Next(time.Date(2019, 4, 10, 10, 0, 0, 0, loc))
+	assert.Equal(t, n, time.Date(2019, 4, 11, 9, 0, 0, 0, loc))
+	assert.Equal(t, n.Location(), loc)
+}
+
+func TestPrev(t *testing.T) {
+	r := MustParse("0 0 1 * *")
+	p := r.Prev(time.Date(2019, 4, 10, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, p, time.Date(2019, 4, 1, 0, 0, 0, 0, time.UTC))
+
+	r = MustParse("0 0 29 2 *")
+	p = r.Prev(time.Date(2019, 4, 10, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, p, time.Date(2016, 2, 29, 0, 0, 0, 0, time.UTC))
+
+	r = MustParse("0 30 23 * * sun")
+	p = r.Prev(time.Date(2019, 4, 10, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, p, time.Date(2019, 4, 7, 23, 30, 0, 0, time.UTC))
+}
+
+func TestNextN(t *testing.T) {
+	r := MustParse("0 0 * * mon-fri")
+	ts := r.NextN(time.Date(2019, 4, 12, 12, 0, 0, 0, time.UTC), 3)
+	assert.Equal(t, ts, []time.Time{
+		time.Date(2019, 4, 15, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 4, 16, 0, 0, 0, 0, time.UTC),
+		time.Date(2019, 4, 17, 0, 0, 0, 0, time.UTC),
+	})
+
+	ts = r.NextN(time.Now(), 0)
+	assert.Equal(t, len(ts), 0)
+}
+
+func TestNever(t *testing.T) {
+	for _, v := range []string{"0 0 30 2 *", "0 0 31 4 *", "0 0 31 2,4,6,9,11 *"} {
+		r := MustParse(v)
+		assert.True(t, r.Next(time.Now()).IsZero(), v)
+		assert.True(t, r.Prev(time.Now()).IsZero(), v)
+		assert.Equal(t, len(r.NextN(time.Now(), 3)), 0, v)
+	}
+}
+
+func TestNextLocation(t *testing.T) {
+	r := MustParse("CRON_TZ=Asia/Shanghai 0 9 * * *")
+	assert.Equal(t, r.Location.String(), "Asia/Shanghai")
+
+	// 00:00 UTC is 08:00 in Shanghai
+	n := r.Next(time.Date(2019, 4, 10, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, n.Location(), r.Location)
+	assert.True(t, n.Equal(time.Date(2019, 4, 10, 1, 0, 0, 0, time.UTC)))
+
+	p := r.Prev(time.Date(2019, 4, 10, 0, 0, 0, 0, time.UTC))
+	assert.True(t, p.Equal(time.Date(2019, 4, 9, 1, 0, 0, 0, time.UTC)))
+}
+
+func TestNextDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04:05 MST", s, loc)
+		assert.Nil(t, err)
+		return v
+	}
+
+	tests := []struct {
+		rule  string
+		after string
+		out   []string
+	}{
+		// 02:00 EST is skipped to 03:00 EDT, skipped wall clock fires once at the transition
+		{"0 30 2 * * *", "2024-03-10 00:00:00 EST",
+			[]string{"2024-03-10 03:00:00 EDT", "2024-03-11 02:30:00 EDT"}},
+		{"0 0 * * * *", "2024-03-10 01:30:00 EST",
+			[]string{"2024-03-10 03:00:00 EDT", "2024-03-10 04:00:00 EDT"}},
+		{"0 */20 2 * * *", "2024-03-10 00:00:00 EST",
+			[]string{"2024-03-10 03:00:00 EDT", "2024-03-11 02:00:00 EDT"}},
+		// 02:00 EDT is turned back to 01:00 EST, repeated wall clock fires once
+		{"0 30 1 * * *", "2024-11-03 00:00:00 EDT",
+			[]string{"2024-11-03 01:30:00 EDT", "2024-11-04 01:30:00 EST"}},
+		{"0 0 * * * *", "2024-11-03 00:30:00 EDT",
+			[]string{"2024-11-03 01:00:00 EDT", "2024-11-03 02:00:00 EST"}},
+		{"0 30 1 * * *", "2024-11-03 01:10:00 EST",
+			[]string{"2024-11-04 01:30:00 EST"}},
+	}
+
+	for _, v := range tests {
+		r := MustParse("CRON_TZ=America/New_York " + v.rule)
+		ts := r.NextN(at(v.after), len(v.out))
+		assert.Equal(t, len(ts), len(v.out), v)
+		for i := range ts {
+			assert.True(t, ts[i].Equal(at(v.out[i])), v, ts[i])
+		}
+	}
+
+	r := MustParse("CRON_TZ=America/New_York 0 30 2 * * *")
+	p := r.Prev(at("2024-03-10 12:00:00 EDT"))
+	assert.True(t, p.Equal(at("2024-03-10 03:00:00 EDT")), p)
+
+	r = MustParse("CRON_TZ=America/New_York 0 30 1 * * *")
+	p = r.Prev(at("2024-11-03 12:00:00 EST"))
+	assert.True(t, p.Equal(at("2024-11-03 01:30:00 EDT")), p)
+}
+
+func TestNextExtended(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02", s)
+		assert.Nil(t, err)
+		return v
+	}
+
+	tests := []struct {
+		rule  string
+		after string
+		out   []string
+	}{
+		// last day of month
+		{"0 0 l * *", "2024-01-15", []string{"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30"}},
+		{"0 0 l-1 * *", "2023-01-31", []string{"2023-02-27", "2023-03-30"}},
+		{"0 0 1,l * *", "2024-01-15", []string{"2024-01-31", "2024-02-01", "2024-02-29"}},
+		// nearest weekday, 2024-06-15 is Saturday, 2024-09-01 is Sunday, 2024-06-01 is Saturday
+		{"0 0 15w * *", "2024-06-01", []string{"2024-06-14", "2024-07-15"}},
+		{"0 0 1w 6 *", "2024-05-01", []string{"2024-06-03"}},
+		{"0 0 1w 9 *", "2024-08-01", []string{"2024-09-02"}},
+		// last weekday, 2024-03-31 is Sunday, 2024-08-31 is Saturday
+		{"0 0 lw 3,8 *", "2024-01-01", []string{"2024-03-29", "2024-08-30"}},
+		{"0 0 31w * *", "2024-02-01", []string{"2024-03-29", "2024-05-31"}},
+		// nth day of week
+		{"0 0 * * 5#3", "2024-01-01", []string{"2024-01-19", "2024-02-16", "2024-03-15"}},
+		{"0 0 * * fri#3", "2024-01-01", []string{"2024-01-19"}},
+		{"0 0 * * 1#5", "2024-01-01", []string{"2024-01-29", "2024-04-29", "2024-07-29"}},
+		{"0 0 * * 5l", "2024-01-01", []string{"2024-01-26", "2024-02-23", "2024-03-29"}},
+		{"0 0 * * fril", "2024-01-01", []string{"2024-01-26"}},
+		{"0 0 * * l", "2024-01-01", []string{"2024-01-06", "2024-01-13"}},
+		{"0 0 * * 0,1#1", "2024-01-01", []string{"2024-01-07", "2024-01-14"}},
+		{"0 0 * * 1#1,0", "2023-12-31", []string{"2024-01-01", "2024-01-07"}},
+	}
+
+	for _, v := range tests {
+		ts := MustParse(v.rule).NextN(at(v.after), len(v.out))
+		out := []time.Time{}
+		for _, o := range v.out {
+			out = append(out, at(o))
+		}
+		assert.Equal(t, ts, out, v)
+	}
+}
+
+func TestNextEvery(t *testing.T) {
+	r := MustParse("@every 1h30m")
+	assert.Equal(t, r.Every, 90*time.Minute)
+
+	now := time.Date(2019, 4, 10, 0, 0, 0, 500, time.UTC)
+	ts := r.NextN(now, 2)
+	assert.Equal(t, ts, []time.Time{
+		time.Date(2019, 4, 10, 1, 30, 0, 0, time.UTC),
+		time.Date(2019, 4, 10, 3, 0, 0, 0, time.UTC),
+	})
+
+	assert.Equal(t, r.Prev(ts[0]), time.Date(2019, 4, 10, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, r.Prev(now), time.Date(2019, 4, 10, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, r.Next(now.Add(time.Hour)), ts[0])
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xcron
+
+import (
+	"container/heap"
+	"time"
+)
+
+// maxSleep is max time the scheduler sleeps, so wall clock changes are noticed
+const maxSleep = time.Minute
+
+// jobQueue is heap of jobs ordered by next fire time
+type jobQueue []*Job
+
+// Len returns number of jobs
+func (q jobQueue) Len() int {
+	return len(q)
+}
+
+// Less returns job i fires before job j
+func (q jobQueue) Less(i, j int) bool {
+	return q[i].next.Before(q[j].next)
+}
+
+// Swap swaps job i and job j
+func (q jobQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+// Push add job to queue
+func (q *jobQueue) Push(x interface{}) {
+	j := x.(*Job)
+	j.index = len(*q)
+	*q = append(*q, j)
+}
+
+// Pop remove the last job from queue
+func (q *jobQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	j := old[n-1]
+	old[n-1] = nil
+	j.index = -1
+	*q = old[:n-1]
+	return j
+}
+
+// loop is the scheduler, it sleeps on the timer until the earliest job is due, and exits when no job left
+func (s *Service) loop(t Timer) {
+	defer s.wg.Done()
+
+	for {
+		s.Lock()
+		if len(s.jobs) == 0 {
+			t.Stop()
+			s.timer = nil
+			s.Unlock()
+			return
+		}
+
+		now := s.clock.Now()
+		due := []dueRun{}
+		for len(s.queue) > 0 && !s.queue[0].next.After(now) {
+			j := s.queue[0]
+			due = append(due, s.due(j, now))
+			s.schedule(j, now)
+		}
+
+		// the due runs are tracked before timer reset, so FakeClock waits for them
+		done := s.track()
+
+		// manual clock never changes without firing timers, so it sleeps until the job is due
+		d := maxSleep
+		if len(s.queue) > 0 {
+			d = s.queue[0].next.Sub(now)
+			if _, ok := s.clock.(manualClock); !ok {
+				d = min(d, maxSleep)
+			}
+		}
+		t.Reset(d)
+		s.Unlock()
+
+		for _, v := range due {
+			s.misfire(v)
+		}
+
+		if len(due) > 0 {
+			s.saveState()
+		}
+		done()
+
+		<-t.C()
+	}
+}
+
+// schedule update the next fire time of job after the time, job never fires is removed from queue,
+// the lock must be held
+func (s *Service) schedule(j *Job, after time.Time) {
+	j.next = j.rules.Next(after)
+	switch {
+	case j.next.IsZero() && j.index >= 0:
+		heap.Remove(&s.queue, j.index)
+	case j.next.IsZero():
+	case j.index >= 0:
+		heap.Fix(&s.queue, j.index)
+	default:
+		heap.Push(&s.queue, j)
+	}
+}
+
+// del remove job from service and stop it, the lock must be held
+func (s *Service) del(id string) {
+	j, ok := s.jobs[id]
+	if !ok {
+		return
+	}
+
+	delete(s.jobs, id)
+	if j.index >= 0 {
+		heap.Remove(&s.queue, j.index)
+	}
+
+	j.stop()
+	go func() {
+		j.runs.Wait()
+		j.tidy()
+		s.wg.Done()
+	}()
+
+	s.notify()
+}
+
+// track add a run in progress if clock is tracking them, returns the func called after the run finished
+func (s *Service) track() func() {
+	if t, ok := s.clock.(tracker); ok {
+		return t.track()
+	}
+
+	return func() {}
+}
+
+// notify wake up the scheduler to check the queue, the lock must be held
+func (s *Service) notify() {
+	if s.timer != nil {
+		s.timer.Reset(0)
+	}
+}
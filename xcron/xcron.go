@@ -21,13 +21,14 @@ package xcron
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/likexian/gokit/assert"
 	"github.com/likexian/gokit/xhash"
 	"github.com/likexian/gokit/xtime"
 )
@@ -43,6 +44,8 @@ const (
 )
 
 var (
+	// fieldNames is name of fields for error message
+	fieldNames = []string{"second", "minute", "hour", "day of month", "month", "day of week"}
 	// MonthsMap is month string to int map
 	MonthsMap = map[string]int{
 		"jan": 1,
@@ -78,22 +81,78 @@ type Rule struct {
 	DayOfMonth []int
 	Month      []int
 	DayOfWeek  []int
+	// LastDayOfMonth is days before the last day of month, 0 for L, n for L-n
+	LastDayOfMonth []int
+	// NearestWeekday is days of month fires on the nearest weekday in the same month, nW, 0 for LW
+	NearestWeekday []int
+	// NthDayOfWeek is days of week fires on the nth one of month, n#k, or the last one, nL
+	NthDayOfWeek []NthWeekday
+	// steps is step of fields parsed from */n, indexed by field type, 0 if the field is not a step
+	steps [6]int
+	// Every is interval of @every duration rule, other fields are ignored if it is set
+	Every time.Duration
+	// Location is time zone of rule, nil for the location of time it is checked with
+	Location *time.Location
 }
 
+// NthWeekday is the nth day of week of month
+type NthWeekday struct {
+	// Weekday is day of week, 0 for Sunday
+	Weekday int
+	// Nth is 1 to 5, -1 for the last one
+	Nth int
+}
+
+// Option is cron job option
+type Option func(*Job)
+
 // Job is a cron job
 type Job struct {
-	rule string
-	loop func()
-	tidy func()
-	stop chan bool
+	id         string
+	rule       string
+	rules      Rule
+	loop       func(ctx context.Context) error
+	tidy       func()
+	loc        *time.Location
+	policy     Policy
+	timeout    time.Duration
+	onError    func(e Event)
+	misfire    Misfire
+	misfireMax int
+	locker     Locker
+	maxHistory int
+	maxQueue   int
+	history    []Run
+	last       Run
+	runCount   int
+	failCount  int
+	next       time.Time
+	index      int
+	ctx        context.Context
+	cancel     context.CancelFunc
+	runs       sync.WaitGroup
+	running    int
+	pending    []time.Time
+	cancels    map[uint64]context.CancelFunc
+	seq        uint64
+	stopped    bool
+	mu         sync.Mutex
 }
 
 // Service is cron service
 type Service struct {
-	jobs   map[string]Job
-	ctx    context.Context
-	cancel context.CancelFunc
-	wg     *sync.WaitGroup
+	jobs    map[string]*Job
+	queue   jobQueue
+	timer   Timer
+	onEvent func(e Event)
+	clock   Clock
+	state   map[string]time.Time
+	fstate  string
+	dirty   bool
+	saving  sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      *sync.WaitGroup
 	sync.RWMutex
 }
 
@@ -126,22 +185,37 @@ func MustParse(s string) Rule {
 // Base on https://en.wikipedia.org/wiki/Cron and extensed
 // Fields: second minute hour dayOfMonth month dayOfWeek
 //   - *      *    *          *     *
+//
+// Time zone of rule can be set by prefix CRON_TZ= or TZ=, for example CRON_TZ=Asia/Shanghai 0 9 * * *
 func Parse(s string) (r Rule, err error) {
 	r = Rule{
-		[]int{},
-		[]int{},
-		[]int{},
-		[]int{},
-		[]int{},
-		[]int{},
+		Second:     []int{},
+		Minute:     []int{},
+		Hour:       []int{},
+		DayOfMonth: []int{},
+		Month:      []int{},
+		DayOfWeek:  []int{},
 	}
 
 	s = strings.TrimSpace(s)
+	r.Location, s, err = parseLocation(s)
+	if err != nil {
+		return
+	}
+
 	if s == "" || s == "*" {
 		return
 	}
 
 	s = strings.ToLower(s)
+	if d, ok := parseEvery(s); ok {
+		if d < time.Second {
+			return r, fmt.Errorf("xcron: interval is less than 1s: %s", s)
+		}
+		r.Every = d
+		return
+	}
+
 	if s[0] == '@' {
 		s, err = parseMacros(s)
 		if err != nil {
@@ -161,7 +235,7 @@ func Parse(s string) (r Rule, err error) {
 	for i := 0; i < len(fs); i++ {
 		err := r.parseField(fs[i], i)
 		if err != nil {
-			return r, err
+			return r, fmt.Errorf("xcron: invalid %s field: %s", fieldNames[i], strings.TrimPrefix(err.Error(), "xcron: "))
 		}
 	}
 
@@ -170,81 +244,163 @@ func Parse(s string) (r Rule, err error) {
 
 // New returns new cron service
 func New() *Service {
+	return NewWithClock(realClock{})
+}
+
+// NewWithClock returns new cron service schedules jobs by the clock, use FakeClock for testing
+func NewWithClock(clock Clock) *Service {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Service{
-		jobs:   map[string]Job{},
+		jobs:   map[string]*Job{},
+		clock:  clock,
 		ctx:    ctx,
 		cancel: cancel,
 		wg:     &sync.WaitGroup{},
 	}
 }
 
+// WithLocation set time zone of job, time zone set in rule by CRON_TZ= takes precedence
+func WithLocation(loc *time.Location) Option {
+	return func(j *Job) {
+		j.loc = loc
+	}
+}
+
+// WithTidy set the func called after job exit
+func WithTidy(f func()) Option {
+	return func(j *Job) {
+		j.tidy = f
+	}
+}
+
 // Add add new cron job to service
 func (s *Service) Add(rule string, loop func(), tidy ...func()) (string, error) {
-	id := xhash.Sha1("xcron", rule, xtime.Ns()).Hex()
-	return id, s.Set(id, rule, loop, tidy...)
+	return s.AddWithOptions(rule, loop, tidyOptions(tidy)...)
 }
 
 // Set update service cron job
 func (s *Service) Set(id, rule string, loop func(), tidy ...func()) error {
-	rules, err := Parse(rule)
+	return s.SetWithOptions(id, rule, loop, tidyOptions(tidy)...)
+}
+
+// AddWithOptions add new cron job with options to service
+func (s *Service) AddWithOptions(rule string, loop func(), opts ...Option) (string, error) {
+	return s.AddFunc(rule, wrapLoop(loop), opts...)
+}
+
+// SetWithOptions update service cron job with options
+func (s *Service) SetWithOptions(id, rule string, loop func(), opts ...Option) error {
+	return s.SetFunc(id, rule, wrapLoop(loop), opts...)
+}
+
+// AddFunc add new cron job with loop func of context to service, the context is canceled
+// when the run is timeout, replaced, or the job is deleted, returned error is reported as event
+func (s *Service) AddFunc(rule string, loop func(ctx context.Context) error, opts ...Option) (string, error) {
+	id := xhash.Sha1("xcron", rule, xtime.Ns()).Hex()
+	j, err := newJob(id, rule, loop, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	// id of added job is not the same across processes
+	if j.locker != nil {
+		return "", ErrLockID
+	}
+
+	s.put(j)
+
+	return id, nil
+}
+
+// SetFunc update service cron job with loop func of context
+func (s *Service) SetFunc(id, rule string, loop func(ctx context.Context) error, opts ...Option) error {
+	j, err := newJob(id, rule, loop, opts...)
 	if err != nil {
 		return err
 	}
 
-	if s.Has(id) {
-		s.Del(id)
+	s.put(j)
+
+	return nil
+}
+
+// tidyOptions returns option of the first tidy func if any
+func tidyOptions(tidy []func()) []Option {
+	if len(tidy) == 0 {
+		return nil
 	}
 
-	done := func() {}
-	if len(tidy) > 0 {
-		done = tidy[0]
+	return []Option{WithTidy(tidy[0])}
+}
+
+// wrapLoop returns loop func of context calls the loop func
+func wrapLoop(loop func()) func(context.Context) error {
+	return func(context.Context) error {
+		loop()
+		return nil
 	}
+}
 
-	j := Job{
-		rule: rule,
-		loop: loop,
-		tidy: done,
-		stop: make(chan bool, 1),
+// newJob returns new job with options applied
+func newJob(id, rule string, loop func(context.Context) error, opts ...Option) (*Job, error) {
+	rules, err := Parse(rule)
+	if err != nil {
+		return nil, err
 	}
 
-	s.Lock()
-	s.jobs[id] = j
-	s.Unlock()
-	s.wg.Add(1)
+	j := &Job{
+		id:         id,
+		rule:       rule,
+		rules:      rules,
+		loop:       loop,
+		tidy:       func() {},
+		policy:     Skip,
+		maxHistory: 10,
+		maxQueue:   1,
+		index:      -1,
+		cancels:    map[uint64]context.CancelFunc{},
+	}
 
-	go func() {
-		t := time.NewTicker(time.Second)
-		for {
-			select {
-			case <-j.stop:
-				t.Stop()
-				j.tidy()
-				s.wg.Done()
-				return
-			case <-s.ctx.Done():
-				s.Del(id)
-			case v := <-t.C:
-				if isDue(v, rules) {
-					j.loop()
-				}
-			}
-		}
-	}()
+	for _, opt := range opts {
+		opt(j)
+	}
 
-	return nil
+	if j.rules.Location == nil {
+		j.rules.Location = j.loc
+	}
+
+	return j, nil
 }
 
-// Del del cron job from service by id
-func (s *Service) Del(id string) {
+// put add the job to service, job of the same id is replaced
+func (s *Service) put(j *Job) {
 	s.Lock()
 	defer s.Unlock()
-	if j, ok := s.jobs[id]; ok {
-		delete(s.jobs, id)
-		close(j.stop)
+
+	s.del(j.id)
+	j.ctx, j.cancel = context.WithCancel(s.ctx)
+	s.jobs[j.id] = j
+	s.wg.Add(1)
+
+	s.resume(j, s.clock.Now())
+	s.notify()
+	if s.timer == nil {
+		s.timer = s.clock.NewTimer(0)
+		s.wg.Add(1)
+		go s.loop(s.timer)
 	}
 }
 
+// Del del cron job from service by id, its state set by SetState is removed
+func (s *Service) Del(id string) {
+	s.Lock()
+	s.del(id)
+	s.forget(id)
+	s.Unlock()
+
+	s.saveState()
+}
+
 // Has returns if cron job is running
 func (s *Service) Has(id string) bool {
 	s.RLock()
@@ -260,9 +416,72 @@ func (s *Service) Len() int {
 	return len(s.jobs)
 }
 
-// Empty empty the cron job service
+// Jobs returns state of all jobs sorted by id
+func (s *Service) Jobs() []JobInfo {
+	s.RLock()
+	defer s.RUnlock()
+
+	r := make([]JobInfo, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		v := j.info()
+		v.Next = j.next
+		r = append(r, v)
+	}
+
+	sort.Slice(r, func(i, j int) bool { return r[i].ID < r[j].ID })
+
+	return r
+}
+
+// Job returns state of job by id
+func (s *Service) Job(id string) (JobInfo, bool) {
+	s.RLock()
+	defer s.RUnlock()
+
+	j, ok := s.jobs[id]
+	if !ok {
+		return JobInfo{}, false
+	}
+
+	v := j.info()
+	v.Next = j.next
+
+	return v, true
+}
+
+// History returns recent finished runs of job, the oldest first
+func (s *Service) History(id string) []Run {
+	s.RLock()
+	j, ok := s.jobs[id]
+	s.RUnlock()
+
+	if !ok {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return append([]Run{}, j.history...)
+}
+
+// OnEvent set the func called when event of job happens, for example a run is skipped
+func (s *Service) OnEvent(f func(e Event)) {
+	s.Lock()
+	defer s.Unlock()
+	s.onEvent = f
+}
+
+// Empty empty the cron job service, context of running runs is canceled
 func (s *Service) Empty() {
+	s.Lock()
+	defer s.Unlock()
+
 	s.cancel()
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	for id := range s.jobs {
+		s.del(id)
+	}
 }
 
 // Wait wait for all cron job exit
@@ -270,40 +489,32 @@ func (s *Service) Wait() {
 	s.wg.Wait()
 }
 
-// isDue check if is due with rule
-func isDue(now time.Time, rule Rule) bool {
-	rules := [][]int{
-		rule.Second,
-		rule.Minute,
-		rule.Hour,
-		rule.DayOfMonth,
-		rule.Month,
-		rule.DayOfWeek,
-	}
-
-	toCheck := []int{}
-	for k, v := range rules {
-		if len(v) > 0 {
-			toCheck = append(toCheck, k)
+// parseLocation parse CRON_TZ= or TZ= prefix of rule, returns the location and the rest of rule
+func parseLocation(s string) (*time.Location, string, error) {
+	var name string
+	var found bool
+	for _, v := range []string{"CRON_TZ=", "TZ="} {
+		if strings.HasPrefix(s, v) {
+			name, s, _ = strings.Cut(s[len(v):], " ")
+			found = true
+			break
 		}
 	}
 
-	if len(toCheck) == 0 {
-		return true
+	if !found {
+		return nil, s, nil
 	}
 
-	_, m, d := now.Date()
-	h, i, s := now.Clock()
-	w := now.Weekday()
+	if name == "" {
+		return nil, s, errors.New("xcron: missing time zone")
+	}
 
-	nows := []int{s, i, h, d, int(m), int(w)}
-	for _, k := range toCheck {
-		if !assert.IsContains(rules[k], nows[k]) {
-			return false
-		}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, s, fmt.Errorf("xcron: unrecognized time zone: %s", name)
 	}
 
-	return true
+	return loc, strings.TrimSpace(s), nil
 }
 
 // parseField parse every fields
@@ -328,9 +539,10 @@ func (r *Rule) parseField(s string, t int) (err error) {
 			r.Hour, err = getRange(s, t, 0, 23)
 		}
 	case DayOfMonth:
-		if strings.Contains(s, ",") {
+		s, err = r.parseDayOfMonth(s)
+		if err == nil && strings.Contains(s, ",") {
 			r.DayOfMonth, err = getField(s, t, 1, 31)
-		} else {
+		} else if err == nil {
 			r.DayOfMonth, err = getRange(s, t, 1, 31)
 		}
 	case Month:
@@ -340,21 +552,89 @@ func (r *Rule) parseField(s string, t int) (err error) {
 			r.Month, err = getRange(s, t, 1, 12)
 		}
 	case DayOfWeek:
-		if strings.Contains(s, ",") {
+		s, err = r.parseDayOfWeek(s)
+		if err == nil && strings.Contains(s, ",") {
 			r.DayOfWeek, err = getField(s, t, 0, 6)
-		} else {
+		} else if err == nil {
 			r.DayOfWeek, err = getRange(s, t, 0, 6)
 		}
 	}
 
+	if err == nil && !strings.Contains(s, ",") {
+		if _, n, ok := strings.Cut(s, "/"); ok {
+			r.steps[t], err = fieldToi(n, t)
+		}
+	}
+
 	return err
 }
 
+// parseDayOfMonth parse L, L-n, nW and LW of day of month, returns the rest
+func (r *Rule) parseDayOfMonth(s string) (string, error) {
+	rest := []string{}
+	for _, v := range strings.Split(s, ",") {
+		switch {
+		case v == "l":
+			r.LastDayOfMonth = append(r.LastDayOfMonth, 0)
+		case strings.HasPrefix(v, "l-"):
+			n, err := strconv.Atoi(v[2:])
+			if err != nil || n < 0 || n > 30 {
+				return "", fmt.Errorf("xcron: unrecognized charset: %s", v)
+			}
+			r.LastDayOfMonth = append(r.LastDayOfMonth, n)
+		case v == "lw":
+			r.NearestWeekday = append(r.NearestWeekday, 0)
+		case strings.HasSuffix(v, "w"):
+			n, err := strconv.Atoi(v[:len(v)-1])
+			if err != nil || n < 1 || n > 31 {
+				return "", fmt.Errorf("xcron: unrecognized charset: %s", v)
+			}
+			r.NearestWeekday = append(r.NearestWeekday, n)
+		default:
+			rest = append(rest, v)
+		}
+	}
+
+	return strings.Join(rest, ","), nil
+}
+
+// parseDayOfWeek parse n#k, nL and L of day of week, returns the rest
+func (r *Rule) parseDayOfWeek(s string) (string, error) {
+	rest := []string{}
+	for _, v := range strings.Split(s, ",") {
+		var w, n string
+		switch {
+		case v == "l":
+			// L alone is the last day of week
+			rest = append(rest, "6")
+			continue
+		case strings.Contains(v, "#"):
+			w, n, _ = strings.Cut(v, "#")
+		case len(v) > 1 && strings.HasSuffix(v, "l"):
+			w, n = v[:len(v)-1], "-1"
+		default:
+			rest = append(rest, v)
+			continue
+		}
+		d, err := fieldToi(w, DayOfWeek)
+		if err != nil || d < 0 || d > 6 {
+			return "", fmt.Errorf("xcron: unrecognized charset: %s", v)
+		}
+		k, err := strconv.Atoi(n)
+		if err != nil || k == 0 || k < -1 || k > 5 {
+			return "", fmt.Errorf("xcron: unrecognized charset: %s", v)
+		}
+		r.NthDayOfWeek = append(r.NthDayOfWeek, NthWeekday{d, k})
+	}
+
+	return strings.Join(rest, ","), nil
+}
+
 // getRange get int array from string range, for example 3, 0-23, */3
 func getRange(s string, t, from, to int) ([]int, error) {
 	r := []int{}
 
-	if s == "*" {
+	if s == "*" || s == "" {
 		return r, nil
 	}
 
@@ -450,6 +730,21 @@ func fieldToi(s string, t int) (int, error) {
 	return 0, fmt.Errorf("xcron: unrecognized charset: %s", s)
 }
 
+// parseEvery parse @every duration, for example @every 90s, @every 1h30m
+func parseEvery(s string) (time.Duration, bool) {
+	every := "@every "
+	if !strings.HasPrefix(s, every) {
+		return 0, false
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(s[len(every):]))
+	if err != nil {
+		return 0, false
+	}
+
+	return d, true
+}
+
 // parseMacros parse nonstandard predefined scheduling definitions
 // returns as standard scheduling definitions
 func parseMacros(s string) (string, error) { //nolint:cyclop
@@ -20,6 +20,7 @@
 package xcron
 
 import (
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -101,65 +102,68 @@ func TestParse(t *testing.T) {
 		out Rule
 		err error
 	}{
-		{"", Rule{[]int{}, []int{}, []int{}, []int{}, []int{}, []int{}}, nil},
-		{"*", Rule{[]int{}, []int{}, []int{}, []int{}, []int{}, []int{}}, nil},
-		{"* * * * *", Rule{[]int{0}, []int{}, []int{}, []int{}, []int{}, []int{}}, nil},
-		{"* * * * * *", Rule{[]int{}, []int{}, []int{}, []int{}, []int{}, []int{}}, nil},
-		{"* * * * jan *", Rule{[]int{}, []int{}, []int{}, []int{}, []int{1}, []int{}}, nil},
-		{"* * * * jan-mar *", Rule{[]int{}, []int{}, []int{}, []int{}, []int{1, 2, 3}, []int{}}, nil},
-		{"* * * * jan,feb,mar *", Rule{[]int{}, []int{}, []int{}, []int{}, []int{1, 2, 3}, []int{}}, nil},
-		{"* * * * * sun", Rule{[]int{}, []int{}, []int{}, []int{}, []int{}, []int{0}}, nil},
-		{"* * * * * sun-tue", Rule{[]int{}, []int{}, []int{}, []int{}, []int{}, []int{0, 1, 2}}, nil},
-		{"* * * * * sun,mon,tue", Rule{[]int{}, []int{}, []int{}, []int{}, []int{}, []int{0, 1, 2}}, nil},
-
-		{"1 * * * * *", Rule{[]int{1}, []int{}, []int{}, []int{}, []int{}, []int{}}, nil},
-		{"* 1 * * * *", Rule{[]int{}, []int{1}, []int{}, []int{}, []int{}, []int{}}, nil},
-		{"* * 1 * * *", Rule{[]int{}, []int{}, []int{1}, []int{}, []int{}, []int{}}, nil},
-		{"* * * 1 * *", Rule{[]int{}, []int{}, []int{}, []int{1}, []int{}, []int{}}, nil},
-		{"* * * * 1 *", Rule{[]int{}, []int{}, []int{}, []int{}, []int{1}, []int{}}, nil},
-		{"* * * * * 1", Rule{[]int{}, []int{}, []int{}, []int{}, []int{}, []int{1}}, nil},
-
-		{"1,2,3 * * * * *", Rule{[]int{1, 2, 3}, []int{}, []int{}, []int{}, []int{}, []int{}}, nil},
-		{"* 1,2,3 * * * *", Rule{[]int{}, []int{1, 2, 3}, []int{}, []int{}, []int{}, []int{}}, nil},
-		{"* * 1,2,3 * * *", Rule{[]int{}, []int{}, []int{1, 2, 3}, []int{}, []int{}, []int{}}, nil},
-		{"* * * 1,2,3 * *", Rule{[]int{}, []int{}, []int{}, []int{1, 2, 3}, []int{}, []int{}}, nil},
-		{"* * * * 1,2,3 *", Rule{[]int{}, []int{}, []int{}, []int{}, []int{1, 2, 3}, []int{}}, nil},
-		{"* * * * * 1,2,3", Rule{[]int{}, []int{}, []int{}, []int{}, []int{}, []int{1, 2, 3}}, nil},
-
-		{"1-3 * * * * *", Rule{[]int{1, 2, 3}, []int{}, []int{}, []int{}, []int{}, []int{}}, nil},
-		{"* 1-3 * * * *", Rule{[]int{}, []int{1, 2, 3}, []int{}, []int{}, []int{}, []int{}}, nil},
-		{"* * 1-3 * * *", Rule{[]int{}, []int{}, []int{1, 2, 3}, []int{}, []int{}, []int{}}, nil},
-		{"* * * 1-3 * *", Rule{[]int{}, []int{}, []int{}, []int{1, 2, 3}, []int{}, []int{}}, nil},
-		{"* * * * 1-3 *", Rule{[]int{}, []int{}, []int{}, []int{}, []int{1, 2, 3}, []int{}}, nil},
-		{"* * * * * 1-3", Rule{[]int{}, []int{}, []int{}, []int{}, []int{}, []int{1, 2, 3}}, nil},
-
-		{"*/20 * * * * *", Rule{[]int{0, 20, 40}, []int{}, []int{}, []int{}, []int{}, []int{}}, nil},
-		{"* */30 * * * *", Rule{[]int{}, []int{0, 30}, []int{}, []int{}, []int{}, []int{}}, nil},
-		{"* * */6 * * *", Rule{[]int{}, []int{}, []int{0, 6, 12, 18}, []int{}, []int{}, []int{}}, nil},
-		{"* * * */10 * *", Rule{[]int{}, []int{}, []int{}, []int{10, 20, 30}, []int{}, []int{}}, nil},
-		{"* * * * */4 *", Rule{[]int{}, []int{}, []int{}, []int{}, []int{4, 8, 12}, []int{}}, nil},
-		{"* * * * * */2", Rule{[]int{}, []int{}, []int{}, []int{}, []int{}, []int{0, 2, 4, 6}}, nil},
-
-		{"@weekly", Rule{[]int{0}, []int{0}, []int{0}, []int{}, []int{}, []int{0}}, nil},
-		{"@hourly", Rule{[]int{0}, []int{0}, []int{}, []int{}, []int{}, []int{}}, nil},
-		{"@daily", Rule{[]int{0}, []int{0}, []int{0}, []int{}, []int{}, []int{}}, nil},
-		{"@monthly", Rule{[]int{0}, []int{0}, []int{0}, []int{1}, []int{}, []int{}}, nil},
-		{"@yearly", Rule{[]int{0}, []int{0}, []int{0}, []int{1}, []int{1}, []int{}}, nil},
-
-		{"@every second", Rule{[]int{}, []int{}, []int{}, []int{}, []int{}, []int{}}, nil},
-		{"@every minute", Rule{[]int{0}, []int{}, []int{}, []int{}, []int{}, []int{}}, nil},
-		{"@every hour", Rule{[]int{0}, []int{0}, []int{}, []int{}, []int{}, []int{}}, nil},
-		{"@every day", Rule{[]int{0}, []int{0}, []int{0}, []int{}, []int{}, []int{}}, nil},
-		{"@every month", Rule{[]int{0}, []int{0}, []int{0}, []int{1}, []int{}, []int{}}, nil},
-		{"@every week", Rule{[]int{0}, []int{0}, []int{0}, []int{}, []int{}, []int{0}}, nil},
-		{"@every year", Rule{[]int{0}, []int{0}, []int{0}, []int{1}, []int{1}, []int{}}, nil},
-
-		{"@every 20 second", Rule{[]int{0, 20, 40}, []int{}, []int{}, []int{}, []int{}, []int{}}, nil},
-		{"@every 30 minute", Rule{[]int{0}, []int{0, 30}, []int{}, []int{}, []int{}, []int{}}, nil},
-		{"@every 6 hour", Rule{[]int{0}, []int{0}, []int{0, 6, 12, 18}, []int{}, []int{}, []int{}}, nil},
-		{"@every 10 day", Rule{[]int{0}, []int{0}, []int{0}, []int{10, 20, 30}, []int{}, []int{}}, nil},
-		{"@every 4 month", Rule{[]int{0}, []int{0}, []int{0}, []int{1}, []int{4, 8, 12}, []int{}}, nil},
-		{"@every 2 dayofweek", Rule{[]int{0}, []int{0}, []int{0}, []int{}, []int{}, []int{0, 2, 4, 6}}, nil},
+		{"", fields([]int{}, []int{}, []int{}, []int{}, []int{}, []int{}), nil},
+		{"*", fields([]int{}, []int{}, []int{}, []int{}, []int{}, []int{}), nil},
+		{"* * * * *", fields([]int{0}, []int{}, []int{}, []int{}, []int{}, []int{}), nil},
+		{"* * * * * *", fields([]int{}, []int{}, []int{}, []int{}, []int{}, []int{}), nil},
+		{"* * * * jan *", fields([]int{}, []int{}, []int{}, []int{}, []int{1}, []int{}), nil},
+		{"* * * * jan-mar *", fields([]int{}, []int{}, []int{}, []int{}, []int{1, 2, 3}, []int{}), nil},
+		{"* * * * jan,feb,mar *", fields([]int{}, []int{}, []int{}, []int{}, []int{1, 2, 3}, []int{}), nil},
+		{"* * * * * sun", fields([]int{}, []int{}, []int{}, []int{}, []int{}, []int{0}), nil},
+		{"* * * * * sun-tue", fields([]int{}, []int{}, []int{}, []int{}, []int{}, []int{0, 1, 2}), nil},
+		{"* * * * * sun,mon,tue", fields([]int{}, []int{}, []int{}, []int{}, []int{}, []int{0, 1, 2}), nil},
+
+		{"1 * * * * *", fields([]int{1}, []int{}, []int{}, []int{}, []int{}, []int{}), nil},
+		{"* 1 * * * *", fields([]int{}, []int{1}, []int{}, []int{}, []int{}, []int{}), nil},
+		{"* * 1 * * *", fields([]int{}, []int{}, []int{1}, []int{}, []int{}, []int{}), nil},
+		{"* * * 1 * *", fields([]int{}, []int{}, []int{}, []int{1}, []int{}, []int{}), nil},
+		{"* * * * 1 *", fields([]int{}, []int{}, []int{}, []int{}, []int{1}, []int{}), nil},
+		{"* * * * * 1", fields([]int{}, []int{}, []int{}, []int{}, []int{}, []int{1}), nil},
+
+		{"1,2,3 * * * * *", fields([]int{1, 2, 3}, []int{}, []int{}, []int{}, []int{}, []int{}), nil},
+		{"* 1,2,3 * * * *", fields([]int{}, []int{1, 2, 3}, []int{}, []int{}, []int{}, []int{}), nil},
+		{"* * 1,2,3 * * *", fields([]int{}, []int{}, []int{1, 2, 3}, []int{}, []int{}, []int{}), nil},
+		{"* * * 1,2,3 * *", fields([]int{}, []int{}, []int{}, []int{1, 2, 3}, []int{}, []int{}), nil},
+		{"* * * * 1,2,3 *", fields([]int{}, []int{}, []int{}, []int{}, []int{1, 2, 3}, []int{}), nil},
+		{"* * * * * 1,2,3", fields([]int{}, []int{}, []int{}, []int{}, []int{}, []int{1, 2, 3}), nil},
+
+		{"1-3 * * * * *", fields([]int{1, 2, 3}, []int{}, []int{}, []int{}, []int{}, []int{}), nil},
+		{"* 1-3 * * * *", fields([]int{}, []int{1, 2, 3}, []int{}, []int{}, []int{}, []int{}), nil},
+		{"* * 1-3 * * *", fields([]int{}, []int{}, []int{1, 2, 3}, []int{}, []int{}, []int{}), nil},
+		{"* * * 1-3 * *", fields([]int{}, []int{}, []int{}, []int{1, 2, 3}, []int{}, []int{}), nil},
+		{"* * * * 1-3 *", fields([]int{}, []int{}, []int{}, []int{}, []int{1, 2, 3}, []int{}), nil},
+		{"* * * * * 1-3", fields([]int{}, []int{}, []int{}, []int{}, []int{}, []int{1, 2, 3}), nil},
+
+		{"*/20 * * * * *", steps(fields([]int{0, 20, 40}, []int{}, []int{}, []int{}, []int{}, []int{}), Second, 20), nil},
+		{"* */30 * * * *", steps(fields([]int{}, []int{0, 30}, []int{}, []int{}, []int{}, []int{}), Minute, 30), nil},
+		{"* * */6 * * *", steps(fields([]int{}, []int{}, []int{0, 6, 12, 18}, []int{}, []int{}, []int{}), Hour, 6), nil},
+		{"* * * */10 * *",
+			steps(fields([]int{}, []int{}, []int{}, []int{10, 20, 30}, []int{}, []int{}), DayOfMonth, 10), nil},
+		{"* * * * */4 *", steps(fields([]int{}, []int{}, []int{}, []int{}, []int{4, 8, 12}, []int{}), Month, 4), nil},
+		{"* * * * * */2", steps(fields([]int{}, []int{}, []int{}, []int{}, []int{}, []int{0, 2, 4, 6}), DayOfWeek, 2), nil},
+
+		{"@weekly", fields([]int{0}, []int{0}, []int{0}, []int{}, []int{}, []int{0}), nil},
+		{"@hourly", fields([]int{0}, []int{0}, []int{}, []int{}, []int{}, []int{}), nil},
+		{"@daily", fields([]int{0}, []int{0}, []int{0}, []int{}, []int{}, []int{}), nil},
+		{"@monthly", fields([]int{0}, []int{0}, []int{0}, []int{1}, []int{}, []int{}), nil},
+		{"@yearly", fields([]int{0}, []int{0}, []int{0}, []int{1}, []int{1}, []int{}), nil},
+
+		{"@every second", fields([]int{}, []int{}, []int{}, []int{}, []int{}, []int{}), nil},
+		{"@every minute", fields([]int{0}, []int{}, []int{}, []int{}, []int{}, []int{}), nil},
+		{"@every hour", fields([]int{0}, []int{0}, []int{}, []int{}, []int{}, []int{}), nil},
+		{"@every day", fields([]int{0}, []int{0}, []int{0}, []int{}, []int{}, []int{}), nil},
+		{"@every month", fields([]int{0}, []int{0}, []int{0}, []int{1}, []int{}, []int{}), nil},
+		{"@every week", fields([]int{0}, []int{0}, []int{0}, []int{}, []int{}, []int{0}), nil},
+		{"@every year", fields([]int{0}, []int{0}, []int{0}, []int{1}, []int{1}, []int{}), nil},
+
+		{"@every 20 second", steps(fields([]int{0, 20, 40}, []int{}, []int{}, []int{}, []int{}, []int{}), Second, 20), nil},
+		{"@every 30 minute", steps(fields([]int{0}, []int{0, 30}, []int{}, []int{}, []int{}, []int{}), Minute, 30), nil},
+		{"@every 6 hour", steps(fields([]int{0}, []int{0}, []int{0, 6, 12, 18}, []int{}, []int{}, []int{}), Hour, 6), nil},
+		{"@every 10 day",
+			steps(fields([]int{0}, []int{0}, []int{0}, []int{10, 20, 30}, []int{}, []int{}), DayOfMonth, 10), nil},
+		{"@every 4 month", steps(fields([]int{0}, []int{0}, []int{0}, []int{1}, []int{4, 8, 12}, []int{}), Month, 4), nil},
+		{"@every 2 dayofweek",
+			steps(fields([]int{0}, []int{0}, []int{0}, []int{}, []int{}, []int{0, 2, 4, 6}), DayOfWeek, 2), nil},
 	}
 
 	for _, v := range tests {
@@ -205,25 +209,199 @@ func TestMustParse(t *testing.T) {
 	assert.NotPanic(t, func() { MustParse("@every second") })
 }
 
-func TestIsDue(t *testing.T) {
+// fires returns rule fires at the time
+func fires(r Rule, at time.Time) bool {
+	return r.Next(at.Add(-time.Second)).Equal(at) && r.Prev(at.Add(time.Second)).Equal(at)
+}
+
+func TestFires(t *testing.T) {
 	tests := []struct {
 		now  time.Time
 		rule Rule
 		out  bool
 	}{
 		{time.Date(2019, 04, 10, 0, 0, 0, 0, time.UTC),
-			Rule{[]int{}, []int{}, []int{}, []int{}, []int{}, []int{}}, true},
+			fields([]int{}, []int{}, []int{}, []int{}, []int{}, []int{}), true},
 		{time.Date(2019, 04, 10, 0, 0, 0, 0, time.UTC),
-			Rule{[]int{0}, []int{}, []int{}, []int{}, []int{}, []int{}}, true},
+			fields([]int{0}, []int{}, []int{}, []int{}, []int{}, []int{}), true},
 		{time.Date(2019, 04, 10, 0, 0, 0, 0, time.UTC),
-			Rule{[]int{0, 1}, []int{}, []int{}, []int{}, []int{}, []int{}}, true},
+			fields([]int{0, 1}, []int{}, []int{}, []int{}, []int{}, []int{}), true},
 		{time.Date(2019, 04, 10, 0, 0, 0, 0, time.UTC),
-			Rule{[]int{1}, []int{}, []int{}, []int{}, []int{}, []int{}}, false},
+			fields([]int{1}, []int{}, []int{}, []int{}, []int{}, []int{}), false},
 		{time.Date(2019, 04, 10, 0, 0, 0, 0, time.UTC),
-			Rule{[]int{1, 2}, []int{}, []int{}, []int{}, []int{}, []int{}}, false},
+			fields([]int{1, 2}, []int{}, []int{}, []int{}, []int{}, []int{}), false},
+		{time.Date(2019, 04, 10, 9, 30, 0, 0, time.UTC), MustParse("30 9 * * *"), true},
+		{time.Date(2019, 04, 10, 9, 30, 1, 0, time.UTC), MustParse("30 9 * * *"), false},
 	}
 
 	for _, v := range tests {
-		assert.Equal(t, isDue(v.now, v.rule), v.out)
+		assert.Equal(t, fires(v.rule, v.now), v.out)
+	}
+}
+
+func TestManyJobs(t *testing.T) {
+	c := New()
+
+	var n int32
+	for i := 0; i < 1000; i++ {
+		_, err := c.Add("@every second", func() { atomic.AddInt32(&n, 1) })
+		assert.Nil(t, err)
+	}
+
+	_, err := c.Add("0 0 30 2 *", func() { atomic.AddInt32(&n, 1000) })
+	assert.Nil(t, err)
+	assert.Equal(t, c.Len(), 1001)
+	assert.Equal(t, c.queue.Len(), 1000)
+
+	time.Sleep(2100 * time.Millisecond)
+	c.Empty()
+	c.Wait()
+
+	v := atomic.LoadInt32(&n)
+	assert.True(t, v >= 1000 && v <= 3000, v)
+	assert.Equal(t, c.Len(), 0)
+	assert.Equal(t, c.queue.Len(), 0)
+
+	// service is reusable after empty
+	_, err = c.Add("@every second", func() { atomic.AddInt32(&n, 1) })
+	assert.Nil(t, err)
+	assert.Equal(t, c.Len(), 1)
+	c.Empty()
+	c.Wait()
+}
+
+func TestQueueOrder(t *testing.T) {
+	c := New()
+	defer c.Wait()
+	defer c.Empty()
+
+	for _, v := range []string{"@yearly", "@hourly", "@every second", "@daily", "@every minute"} {
+		_, err := c.Add(v, func() {})
+		assert.Nil(t, err)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	assert.Equal(t, c.queue[0].rule, "@every second")
+	for i := range c.queue {
+		assert.Equal(t, c.queue[i].index, i)
+	}
+}
+
+// steps returns rule with step of field set
+func steps(r Rule, t, n int) Rule {
+	r.steps[t] = n
+	return r
+}
+
+// fields returns rule of fields
+func fields(second, minute, hour, dayOfMonth, month, dayOfWeek []int) Rule {
+	return Rule{
+		Second:     second,
+		Minute:     minute,
+		Hour:       hour,
+		DayOfMonth: dayOfMonth,
+		Month:      month,
+		DayOfWeek:  dayOfWeek,
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	r, err := Parse("CRON_TZ=Asia/Tokyo 0 9 * * *")
+	assert.Nil(t, err)
+	assert.Equal(t, r.Location.String(), "Asia/Tokyo")
+	assert.Equal(t, r.Hour, []int{9})
+
+	r, err = Parse("TZ=UTC @daily")
+	assert.Nil(t, err)
+	assert.Equal(t, r.Location, time.UTC)
+	assert.Equal(t, r.Hour, []int{0})
+
+	r, err = Parse("0 9 * * *")
+	assert.Nil(t, err)
+	assert.True(t, r.Location == nil)
+
+	_, err = Parse("CRON_TZ=Mars/Olympus 0 9 * * *")
+	assert.NotNil(t, err)
+
+	_, err = Parse("CRON_TZ= 0 9 * * *")
+	assert.NotNil(t, err)
+	_, err = Parse("TZ=")
+	assert.NotNil(t, err)
+
+	loc := time.FixedZone("UTC+8", 8*3600)
+	assert.True(t, fires(MustParse("CRON_TZ=Asia/Shanghai 0 9 * * *"), time.Date(2019, 4, 10, 1, 0, 0, 0, time.UTC)))
+	assert.False(t, fires(MustParse("CRON_TZ=Asia/Shanghai 0 9 * * *"), time.Date(2019, 4, 10, 9, 0, 0, 0, time.UTC)))
+	assert.True(t, fires(MustParse("0 9 * * *"), time.Date(2019, 4, 10, 9, 0, 0, 0, loc)))
+}
+
+func TestWithLocation(t *testing.T) {
+	c := New()
+	defer c.Wait()
+	defer c.Empty()
+
+	loc := time.FixedZone("UTC+8", 8*3600)
+	id, err := c.AddWithOptions("0 9 * * *", func() {}, WithLocation(loc))
+	assert.Nil(t, err)
+
+	id2, err := c.AddWithOptions("CRON_TZ=UTC 0 9 * * *", func() {}, WithTidy(func() {}), WithLocation(loc))
+	assert.Nil(t, err)
+
+	tidys := []func(){func() {}}
+	err = c.Set("x", "0 9 * * *", func() {}, tidys...)
+	assert.Nil(t, err)
+
+	c.RLock()
+	defer c.RUnlock()
+
+	assert.Equal(t, c.jobs[id].next.Location(), loc)
+	assert.Equal(t, c.jobs[id].next.Hour(), 9)
+	assert.Equal(t, c.jobs[id2].next.Location(), time.UTC)
+}
+
+func TestParseExtended(t *testing.T) {
+	r, err := Parse("0 0 1,l,l-2,15w,lw * *")
+	assert.Nil(t, err)
+	assert.Equal(t, r.DayOfMonth, []int{1})
+	assert.Equal(t, r.LastDayOfMonth, []int{0, 2})
+	assert.Equal(t, r.NearestWeekday, []int{15, 0})
+
+	r, err = Parse("0 0 * * MON#2,5L,sun")
+	assert.Nil(t, err)
+	assert.Equal(t, r.DayOfWeek, []int{0})
+	assert.Equal(t, r.NthDayOfWeek, []NthWeekday{{1, 2}, {5, -1}})
+
+	r, err = Parse("0 0 L * *")
+	assert.Nil(t, err)
+	assert.Equal(t, r.DayOfMonth, []int{})
+
+	r, err = Parse("@every 90s")
+	assert.Nil(t, err)
+	assert.Equal(t, r.Every, 90*time.Second)
+
+	_, err = Parse("@every 500ms")
+	assert.NotNil(t, err)
+
+	fails := map[string]string{
+		"0 0 l-31 * *":   "day of month",
+		"0 0 32w * *":    "day of month",
+		"0 0 xw * *":     "day of month",
+		"0 0 * * 7#1":    "day of week",
+		"0 0 * * 1#6":    "day of week",
+		"0 0 * * 1#x":    "day of week",
+		"0 0 * * xl":     "day of week",
+		"x 0 0 * * *":    "second",
+		"x 0 * * *":      "minute",
+		"0 24 * * *":     "hour",
+		"0 0 * 13 *":     "month",
+		"0 0 * * 8":      "day of week",
+		"0 0 0 * * sunx": "day of week",
+	}
+
+	for k, v := range fails {
+		_, err := Parse(k)
+		assert.NotNil(t, err, k)
+		assert.Contains(t, err.Error(), "invalid "+v+" field", k)
 	}
 }
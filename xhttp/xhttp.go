@@ -50,7 +50,7 @@ var (
 	DefaultRequest = New()
 
 	// Caching is http request cache
-	caching = xcache.New(xcache.MemoryCache)
+	caching = xcache.NewCache[string, *Response]()
 
 	// supportMethod list all supported http method
 	supportMethod = []string{
@@ -671,9 +671,8 @@ func (r *Request) Do(ctx context.Context, //nolint:cyclop
 				body = d[1]
 			}
 			s.CacheKey = xhash.Sha1(s.Method, s.URL.String(), body).Hex()
-			cacheVal := caching.Get(s.CacheKey)
-			if cacheVal != nil {
-				s = cacheVal.(*Response)
+			if cacheVal, ok := caching.Get(s.CacheKey); ok {
+				s = cacheVal
 				return
 			}
 		}
@@ -703,7 +702,7 @@ func (r *Request) Do(ctx context.Context, //nolint:cyclop
 	}
 
 	if s.CacheKey != "" {
-		_ = caching.Set(s.CacheKey, s, cacheTTL)
+		_ = caching.Set(s.CacheKey, s, time.Duration(cacheTTL)*time.Second)
 	}
 
 	return
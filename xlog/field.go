@@ -0,0 +1,172 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xlog
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// badKey is key of value without key
+const badKey = "!BADKEY"
+
+// Field storing a key-value field of log, value of []Field is a group of fields
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// With returns a child logger carries the fields, kv is key-value pairs or Field,
+// the child logger shares output, level and flag with its parent
+func (l *Logger) With(kv ...interface{}) *Logger {
+	return &Logger{logger: l.logger, fields: l.withFields(kv)}
+}
+
+// Logw do log a msg with key-value fields
+func (l *Logger) Logw(level LogLevel, msg string, kv ...interface{}) {
+	l.output(level, 2, msg, l.withFields(kv))
+}
+
+// Debugw level msg logging with key-value fields
+func (l *Logger) Debugw(msg string, kv ...interface{}) {
+	l.output(DEBUG, 2, msg, l.withFields(kv))
+}
+
+// Infow level msg logging with key-value fields
+func (l *Logger) Infow(msg string, kv ...interface{}) {
+	l.output(INFO, 2, msg, l.withFields(kv))
+}
+
+// Warnw level msg logging with key-value fields
+func (l *Logger) Warnw(msg string, kv ...interface{}) {
+	l.output(WARN, 2, msg, l.withFields(kv))
+}
+
+// Errorw level msg logging with key-value fields
+func (l *Logger) Errorw(msg string, kv ...interface{}) {
+	l.output(ERROR, 2, msg, l.withFields(kv))
+}
+
+// Fatalw level msg logging with key-value fields, followed by a call to os.Exit(1)
+func (l *Logger) Fatalw(msg string, kv ...interface{}) {
+	l.output(FATAL, 2, msg, l.withFields(kv))
+	l.Close()
+	os.Exit(1)
+}
+
+// Fields returns fields carried by logger
+func (l *Logger) Fields() []Field {
+	return append([]Field{}, l.fields...)
+}
+
+// withFields returns fields of logger followed by the key-value fields
+func (l *Logger) withFields(kv []interface{}) []Field {
+	if len(kv) == 0 {
+		return l.fields
+	}
+
+	fields := make([]Field, len(l.fields), len(l.fields)+len(kv))
+	copy(fields, l.fields)
+
+	return append(fields, toFields(kv)...)
+}
+
+// toFields returns fields of key-value pairs, value without key is set to key !BADKEY
+func toFields(kv []interface{}) []Field {
+	fields := make([]Field, 0, len(kv))
+	for i := 0; i < len(kv); i++ {
+		switch v := kv[i].(type) {
+		case Field:
+			fields = append(fields, v)
+		case string:
+			if i+1 < len(kv) {
+				fields = append(fields, Field{Key: v, Value: kv[i+1]})
+				i++
+			} else {
+				fields = append(fields, Field{Key: badKey, Value: v})
+			}
+		default:
+			fields = append(fields, Field{Key: badKey, Value: v})
+		}
+	}
+
+	return fields
+}
+
+// formatFields returns fields as key=value separated by space, with a leading space,
+// fields in group are keyed as group.key
+func formatFields(fields []Field) string {
+	var b strings.Builder
+	writeFields(&b, "", fields)
+	return b.String()
+}
+
+// writeFields write fields as key=value to builder, key is prefixed by the prefix
+func writeFields(b *strings.Builder, prefix string, fields []Field) {
+	for _, v := range fields {
+		if vv, ok := v.Value.([]Field); ok {
+			writeFields(b, prefix+v.Key+".", vv)
+			continue
+		}
+		b.WriteString(" ")
+		b.WriteString(prefix)
+		b.WriteString(v.Key)
+		b.WriteString("=")
+		b.WriteString(formatValue(v.Value))
+	}
+}
+
+// formatValue returns value as text, it is quoted if contains space, quote or equal sign
+func formatValue(v interface{}) string {
+	var s string
+	switch vv := v.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		s = vv
+	case error:
+		if isNilPtr(vv) {
+			return "<nil>"
+		}
+		s = vv.Error()
+	case fmt.Stringer:
+		if isNilPtr(vv) {
+			return "<nil>"
+		}
+		s = vv.String()
+	default:
+		s = fmt.Sprint(vv)
+	}
+
+	if s == "" || strings.ContainsAny(s, " =\"\t\r\n") {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
+
+// isNilPtr returns value is a typed nil pointer, calling its methods may panic
+func isNilPtr(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xlog
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/likexian/gokit/assert"
+)
+
+func TestWith(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, DEBUG)
+	log.SetFlag(0)
+
+	req := log.With("req", "abc", "user", 1)
+	req.Info("This is %s", "Info")
+	req.Infow("login", "ok", true, "cost", 1.5)
+	req.With("step", 2).Warnw("retry", "err", errors.New("timeout error"))
+	req.Errorw("failed", Field{Key: "msg", Value: "a=b"}, "empty", "")
+	log.Debugw("This is Debug", "odd")
+	log.Logw(INFO, "nil", "v", nil, 1)
+	log.Close()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, lines, []string{
+		"[INFO] This is Info req=abc user=1",
+		"[INFO] login req=abc user=1 ok=true cost=1.5",
+		`[WARN] retry req=abc user=1 step=2 err="timeout error"`,
+		`[ERROR] failed req=abc user=1 msg="a=b" empty=""`,
+		"[DEBUG] This is Debug !BADKEY=odd",
+		"[INFO] nil v=<nil> !BADKEY=1",
+	})
+
+	assert.Equal(t, len(log.Fields()), 0)
+	assert.Equal(t, req.Fields(), []Field{{Key: "req", Value: "abc"}, {Key: "user", Value: 1}})
+}
+
+func TestWithLevel(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, INFO)
+	log.SetFlag(Lshortfile)
+
+	req := log.With("req", "abc")
+	req.Debugw("This is Debug")
+	log.SetLevel(WARN)
+	req.Infow("This is Info")
+	req.Warnw("This is Warn")
+	req.Close()
+
+	assert.Equal(t, strings.TrimSpace(buf.String()), "field_test.go:69 [WARN] This is Warn req=abc")
+}
+
+type testError struct {
+	msg string
+}
+
+func (e *testError) Error() string {
+	return e.msg
+}
+
+func TestWithNilPointer(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, INFO)
+	log.SetFlag(0)
+
+	var err *testError
+	var tm *time.Time
+	log.Infow("nil", "err", err, "time", tm, "list", []int(nil))
+	log.Close()
+
+	assert.Equal(t, strings.TrimSpace(buf.String()), "[INFO] nil err=<nil> time=<nil> list=[]")
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Entry storing a log entry to format
+type Entry struct {
+	// Time is time of log, in UTC if flag LUTC is set
+	Time time.Time
+	// Level is level of log
+	Level LogLevel
+	// Caller is file:line of caller, ???:? if unknown
+	Caller string
+	// PC is program counter of caller, 0 if unknown
+	PC uintptr
+	// Message is message of log
+	Message string
+	// Fields is fields of log, value of []Field is a group of fields
+	Fields []Field
+	// Flag is flag of logger
+	Flag LogFlag
+}
+
+// Formatter is formatter of log entry
+type Formatter interface {
+	// Format returns log line of entry, ends with newline
+	Format(e Entry) string
+}
+
+// TextFormatter is formatter of text line, as date time file [LEVEL] msg key=value,
+// date, time and file are set by flag
+type TextFormatter struct{}
+
+// JSONFormatter is formatter of JSON line, with time, level, caller, msg and fields,
+// group of fields is encoded as nested object
+type JSONFormatter struct{}
+
+// LogfmtFormatter is formatter of logfmt line, with time, level, caller, msg and fields
+type LogfmtFormatter struct{}
+
+// Format returns text line of entry
+func (TextFormatter) Format(e Entry) string {
+	logTime := ""
+	if e.Flag&(Ldate|Ltime|Lmicroseconds) != 0 {
+		if e.Flag&Ldate != 0 {
+			logTime += fmt.Sprintf("%s ", e.Time.Format("2006-01-02"))
+		}
+		if e.Flag&Ltime != 0 {
+			logTime += fmt.Sprintf("%s ", e.Time.Format("15:04:05"))
+		}
+		if e.Flag&Lmicroseconds != 0 {
+			logTime = fmt.Sprintf("%s.%d ", strings.TrimSpace(logTime), e.Time.Nanosecond()/1e3)
+		}
+	}
+
+	logFile := ""
+	if e.Flag&(Llongfile|Lshortfile) != 0 {
+		logFile = e.caller() + " "
+	}
+
+	return fmt.Sprintf("%s%s[%s] %s%s\n", logTime, logFile, levelMap[e.Level], e.Message, formatFields(e.Fields))
+}
+
+// Format returns JSON line of entry, fields are encoded as JSON values,
+// value that is not JSON encodable is encoded as string
+func (JSONFormatter) Format(e Entry) string {
+	var b bytes.Buffer
+	b.WriteString(`{"time":`)
+	writeJSON(&b, e.Time.Format(time.RFC3339Nano))
+	b.WriteString(`,"level":`)
+	writeJSON(&b, levelMap[e.Level])
+	b.WriteString(`,"caller":`)
+	writeJSON(&b, e.caller())
+	b.WriteString(`,"msg":`)
+	writeJSON(&b, e.Message)
+
+	for _, v := range e.Fields {
+		b.WriteString(",")
+		writeJSON(&b, v.Key)
+		b.WriteString(":")
+		writeJSON(&b, v.Value)
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// Format returns logfmt line of entry
+func (LogfmtFormatter) Format(e Entry) string {
+	return fmt.Sprintf("time=%s level=%s caller=%s msg=%s%s\n", e.Time.Format(time.RFC3339Nano),
+		levelMap[e.Level], formatValue(e.caller()), formatValue(e.Message), formatFields(e.Fields))
+}
+
+// caller returns caller of entry, only the file name if flag Lshortfile is set
+func (e Entry) caller() string {
+	if e.Flag&Lshortfile != 0 {
+		ls := strings.Split(e.Caller, "/")
+		return ls[len(ls)-1]
+	}
+
+	return e.Caller
+}
+
+// writeJSON write JSON value to buffer, error is encoded as its message
+func writeJSON(b *bytes.Buffer, v interface{}) {
+	switch vv := v.(type) {
+	case error:
+		if isNilPtr(vv) {
+			v = nil
+		} else {
+			v = vv.Error()
+		}
+	case []Field:
+		b.WriteString("{")
+		for i, f := range vv {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			writeJSON(b, f.Key)
+			b.WriteString(":")
+			writeJSON(b, f.Value)
+		}
+		b.WriteString("}")
+		return
+	}
+
+	bs, err := json.Marshal(v)
+	if err != nil {
+		bs, _ = json.Marshal(fmt.Sprint(v))
+	}
+
+	b.Write(bs)
+}
@@ -0,0 +1,132 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/likexian/gokit/assert"
+)
+
+func TestTextFormatter(t *testing.T) {
+	e := Entry{
+		Time:    time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC),
+		Level:   INFO,
+		Caller:  "/root/app/main.go:10",
+		Message: "This is Info",
+		Fields:  []Field{{Key: "user", Value: "a b"}},
+	}
+
+	tests := []struct {
+		flag LogFlag
+		out  string
+	}{
+		{0, `[INFO] This is Info user="a b"`},
+		{LstdFlags, `2026-01-02 03:04:05 [INFO] This is Info user="a b"`},
+		{LstdFlags | Lmicroseconds, `2026-01-02 03:04:05.6 [INFO] This is Info user="a b"`},
+		{Llongfile, `/root/app/main.go:10 [INFO] This is Info user="a b"`},
+		{LstdFlags | Lshortfile, `2026-01-02 03:04:05 main.go:10 [INFO] This is Info user="a b"`},
+	}
+
+	for _, v := range tests {
+		e.Flag = v.flag
+		assert.Equal(t, TextFormatter{}.Format(e), v.out+"\n")
+	}
+}
+
+func TestJSONFormatter(t *testing.T) {
+	e := Entry{
+		Time:    time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC),
+		Level:   ERROR,
+		Caller:  "/root/app/main.go:10",
+		Message: `say "hi"`,
+		Fields: []Field{
+			{Key: "id", Value: 1},
+			{Key: "ok", Value: true},
+			{Key: "tags", Value: []string{"a", "b"}},
+			{Key: "err", Value: errors.New("failed")},
+			{Key: "ch", Value: make(chan int)},
+			{Key: "nil", Value: nil},
+			{Key: "nilerr", Value: (*testError)(nil)},
+			{Key: "niltime", Value: (*time.Time)(nil)},
+		},
+		Flag: Lshortfile,
+	}
+
+	out := JSONFormatter{}.Format(e)
+	assert.True(t, strings.HasSuffix(out, "}\n"))
+	assert.True(t, strings.HasPrefix(out, `{"time":"2026-01-02T03:04:05.000006Z","level":"ERROR","caller":"main.go:10"`))
+
+	m := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal([]byte(out), &m))
+	assert.Equal(t, m["msg"], `say "hi"`)
+	assert.Equal(t, m["id"], float64(1))
+	assert.Equal(t, m["ok"], true)
+	assert.Equal(t, m["tags"], []interface{}{"a", "b"})
+	assert.Equal(t, m["err"], "failed")
+	assert.Contains(t, m["ch"].(string), "0x")
+	assert.Nil(t, m["nil"])
+	assert.Nil(t, m["nilerr"])
+	assert.Nil(t, m["niltime"])
+	assert.Contains(t, m, "nilerr")
+}
+
+func TestLogfmtFormatter(t *testing.T) {
+	e := Entry{
+		Time:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level:   WARN,
+		Caller:  "/root/my app/main.go:10",
+		Message: "disk full",
+		Fields:  []Field{{Key: "used", Value: 0.95}, {Key: "path", Value: "/data"}},
+	}
+
+	assert.Equal(t, LogfmtFormatter{}.Format(e),
+		"time=2026-01-02T03:04:05Z level=WARN caller=\"/root/my app/main.go:10\" msg=\"disk full\" used=0.95 path=/data\n")
+}
+
+func TestSetFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, DEBUG)
+	log.SetFlag(Lshortfile)
+	log.SetFormatter(JSONFormatter{})
+	log.With("req", "abc").Infow("login", "user", 1)
+	log.SetFormatter(LogfmtFormatter{})
+	log.Warn("This is %s", "Warn")
+	log.Close()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, len(lines), 2)
+
+	m := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &m))
+	assert.Equal(t, m["level"], "INFO")
+	assert.Equal(t, m["caller"], "format_test.go:114")
+	assert.Equal(t, m["msg"], "login")
+	assert.Equal(t, m["req"], "abc")
+	assert.Equal(t, m["user"], float64(1))
+
+	assert.True(t, strings.HasPrefix(lines[1], "time="))
+	assert.True(t, strings.HasSuffix(lines[1], ` level=WARN caller=format_test.go:116 msg="This is Warn"`))
+}
@@ -0,0 +1,209 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xlog
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// slogFatal is slog level of FATAL
+const slogFatal = slog.LevelError + 4
+
+// slogHandler is slog.Handler writes to logger
+type slogHandler struct {
+	logger *Logger
+	groups []string
+	fields [][]Field
+}
+
+// NewSlogHandler returns a slog.Handler writes records to logger, attributes are mapped to fields
+// and groups are mapped to group of fields, the logger's queue, formatter and rotation are used
+func NewSlogHandler(l *Logger) slog.Handler {
+	return &slogHandler{logger: l, fields: [][]Field{l.Fields()}}
+}
+
+// NewSlogLogger returns a new logger writes into slog handler, fields are mapped to attributes
+// and group of fields is mapped to group
+func NewSlogLogger(h slog.Handler, level LogLevel) *Logger {
+	l := newLog(logFile{}, level, LstdFlags)
+	l.logHandler = h
+	return l
+}
+
+// Enabled returns if the level is logged by logger
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.enabled(fromSlogLevel(level))
+}
+
+// Handle write the record to logger
+func (h *slogHandler) Handle(_ context.Context, r slog.Record) error {
+	level := fromSlogLevel(r.Level)
+	if !h.logger.enabled(level) {
+		return nil
+	}
+
+	fields := make([]Field, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		fields = appendAttr(fields, a)
+		return true
+	})
+
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	h.logger.write(Entry{Time: t, Level: level, PC: r.PC, Message: r.Message, Fields: h.nest(fields)})
+
+	return nil
+}
+
+// WithAttrs returns a new handler with the attributes in the current group
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	n := &slogHandler{logger: h.logger, groups: h.groups, fields: append([][]Field{}, h.fields...)}
+	last := len(n.fields) - 1
+	fields := append([]Field{}, n.fields[last]...)
+	for _, a := range attrs {
+		fields = appendAttr(fields, a)
+	}
+	n.fields[last] = fields
+
+	return n
+}
+
+// WithGroup returns a new handler with the group opened
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &slogHandler{
+		logger: h.logger,
+		groups: append(append([]string{}, h.groups...), name),
+		fields: append(append([][]Field{}, h.fields...), nil),
+	}
+}
+
+// nest returns fields of the handler with the fields in the innermost group, empty group is omitted
+func (h *slogHandler) nest(fields []Field) []Field {
+	n := len(h.groups)
+	r := append(append([]Field{}, h.fields[n]...), fields...)
+	for i := n - 1; i >= 0; i-- {
+		fs := append([]Field{}, h.fields[i]...)
+		if len(r) > 0 {
+			fs = append(fs, Field{Key: h.groups[i], Value: r})
+		}
+		r = fs
+	}
+
+	return r
+}
+
+// appendAttr append attribute as field, group is appended as group of fields,
+// or inlined if its key is empty, empty attribute and group are ignored
+func appendAttr(fields []Field, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		return append(fields, Field{Key: a.Key, Value: a.Value.Any()})
+	}
+
+	var group []Field
+	for _, v := range a.Value.Group() {
+		group = appendAttr(group, v)
+	}
+
+	switch {
+	case len(group) == 0:
+		return fields
+	case a.Key == "":
+		return append(fields, group...)
+	default:
+		return append(fields, Field{Key: a.Key, Value: group})
+	}
+}
+
+// handleSlog pass the entry to slog handler
+func handleSlog(h slog.Handler, e Entry) {
+	level := toSlogLevel(e.Level)
+	ctx := context.Background()
+	if !h.Enabled(ctx, level) {
+		return
+	}
+
+	r := slog.NewRecord(e.Time, level, e.Message, e.PC)
+	r.AddAttrs(toAttrs(e.Fields)...)
+	_ = h.Handle(ctx, r)
+}
+
+// toAttrs returns attributes of fields, group of fields is mapped to group
+func toAttrs(fields []Field) []slog.Attr {
+	attrs := make([]slog.Attr, len(fields))
+	for i, v := range fields {
+		if group, ok := v.Value.([]Field); ok {
+			attrs[i] = slog.Attr{Key: v.Key, Value: slog.GroupValue(toAttrs(group)...)}
+		} else {
+			attrs[i] = slog.Any(v.Key, v.Value)
+		}
+	}
+
+	return attrs
+}
+
+// toSlogLevel returns slog level of log level
+func toSlogLevel(level LogLevel) slog.Level {
+	switch level {
+	case DEBUG:
+		return slog.LevelDebug
+	case INFO:
+		return slog.LevelInfo
+	case WARN:
+		return slog.LevelWarn
+	case ERROR:
+		return slog.LevelError
+	default:
+		return slogFatal
+	}
+}
+
+// fromSlogLevel returns log level of slog level
+func fromSlogLevel(level slog.Level) LogLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return DEBUG
+	case level < slog.LevelWarn:
+		return INFO
+	case level < slog.LevelError:
+		return WARN
+	case level < slogFatal:
+		return ERROR
+	default:
+		return FATAL
+	}
+}
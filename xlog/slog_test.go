@@ -0,0 +1,122 @@
+/*
+ * Copyright 2012-2026 Li Kexian
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * A toolkit for Golang development
+ * https://www.likexian.com/
+ */
+
+package xlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/likexian/gokit/assert"
+)
+
+func TestSlogHandler(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, INFO)
+	log.SetFlag(Lshortfile)
+	log.SetFormatter(JSONFormatter{})
+
+	s := slog.New(NewSlogHandler(log.With("app", "test")))
+	s.Debug("This is Debug")
+	s.With("svc", "api").WithGroup("req").Info("login", "id", 1, slog.Group("user", "name", "a"))
+	s.WithGroup("empty").Warn("no attrs", slog.Group("none"))
+	s.Error("failed", "err", errors.New("timeout"), slog.Group("", "inline", true))
+	s.Log(t.Context(), slog.LevelError+4, "fatal")
+	log.Close()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, len(lines), 4)
+
+	m := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &m))
+	assert.Equal(t, m["level"], "INFO")
+	assert.Equal(t, m["caller"], "slog_test.go:41")
+	assert.Equal(t, m["msg"], "login")
+	assert.Equal(t, m["app"], "test")
+	assert.Equal(t, m["svc"], "api")
+	assert.Equal(t, m["req"], map[string]interface{}{"id": float64(1), "user": map[string]interface{}{"name": "a"}})
+
+	m = map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &m))
+	assert.Equal(t, m["level"], "WARN")
+	assert.Nil(t, m["empty"])
+	assert.Nil(t, m["none"])
+
+	m = map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[2]), &m))
+	assert.Equal(t, m["level"], "ERROR")
+	assert.Equal(t, m["err"], "timeout")
+	assert.Equal(t, m["inline"], true)
+
+	m = map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[3]), &m))
+	assert.Equal(t, m["level"], "FATAL")
+}
+
+func TestSlogHandlerText(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, DEBUG)
+	log.SetFlag(0)
+
+	s := slog.New(NewSlogHandler(log)).WithGroup("req").With("id", 1)
+	s.Debug("login", slog.Group("user", "name", "a b"))
+	log.Close()
+
+	assert.Equal(t, buf.String(), "[DEBUG] login req.id=1 req.user.name=\"a b\"\n")
+}
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true, Level: slog.LevelDebug})
+
+	log := NewSlogLogger(h, INFO)
+	log.Debug("This is Debug")
+	log.With("app", "test").Infow("login", "id", 1, "user", []Field{{Key: "name", Value: "a"}})
+	log.Warn("This is %s", "Warn")
+	log.Logw(FATAL, "fatal")
+	log.Close()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, len(lines), 3)
+
+	m := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &m))
+	assert.Equal(t, m["level"], "INFO")
+	assert.Equal(t, m["msg"], "login")
+	assert.Equal(t, m["app"], "test")
+	assert.Equal(t, m["id"], float64(1))
+	assert.Equal(t, m["user"], map[string]interface{}{"name": "a"})
+
+	source := m["source"].(map[string]interface{})
+	assert.True(t, strings.HasSuffix(source["file"].(string), "slog_test.go"))
+	assert.Equal(t, source["line"], float64(94))
+
+	m = map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &m))
+	assert.Equal(t, m["level"], "WARN")
+	assert.Equal(t, m["msg"], "This is Warn")
+
+	m = map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[2]), &m))
+	assert.Equal(t, m["level"], "ERROR+4")
+}
@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -76,12 +77,20 @@ type LogFlag int
 
 // Logger storing logger
 type Logger struct {
-	logFile   logFile
-	logLevel  LogLevel
-	logFlag   LogFlag
-	logQueue  chan string
-	logExit   chan bool
-	logClosed bool
+	*logger
+	fields []Field
+}
+
+// logger storing logger state shared by child loggers
+type logger struct {
+	logFile      logFile
+	logLevel     LogLevel
+	logFlag      LogFlag
+	logFormatter Formatter
+	logHandler   slog.Handler
+	logQueue     chan string
+	logExit      chan bool
+	logClosed    bool
 	sync.RWMutex
 }
 
@@ -134,14 +143,15 @@ func openFile(fname string) (*os.File, error) {
 
 // newLogger returns a new file logger
 func newLog(lf logFile, level LogLevel, flag LogFlag) *Logger {
-	l := &Logger{
-		logFile:   lf,
-		logLevel:  level,
-		logFlag:   flag,
-		logQueue:  make(chan string, 10000),
-		logExit:   make(chan bool),
-		logClosed: false,
-	}
+	l := &Logger{logger: &logger{
+		logFile:      lf,
+		logLevel:     level,
+		logFlag:      flag,
+		logFormatter: TextFormatter{},
+		logQueue:     make(chan string, 10000),
+		logExit:      make(chan bool),
+		logClosed:    false,
+	}}
 	go l.writeLog()
 	return l
 }
@@ -169,6 +179,13 @@ func (l *Logger) SetFlag(flag LogFlag) {
 	l.Unlock()
 }
 
+// SetFormatter set the log formatter, default is TextFormatter
+func (l *Logger) SetFormatter(f Formatter) {
+	l.Lock()
+	l.logFormatter = f
+	l.Unlock()
+}
+
 // SetDailyRotate set daily log rotate
 func (l *Logger) SetDailyRotate(rotateNum int64) error {
 	return l.SetRotate("date", rotateNum, 0)
@@ -314,51 +331,65 @@ func (l *Logger) rotateFile() (err error) {
 
 // Log do log a msg
 func (l *Logger) Log(level LogLevel, msg string, args ...interface{}) {
-	if l.logClosed {
+	if !l.enabled(level) {
 		return
 	}
 
-	if l.logLevel > level {
+	l.output(level, 3, fmt.Sprintf(msg, args...), l.fields)
+}
+
+// output do log a msg with fields, skip is the number of stack frames to the caller
+func (l *Logger) output(level LogLevel, skip int, msg string, fields []Field) {
+	if !l.enabled(level) {
 		return
 	}
 
-	if _, ok := levelMap[level]; !ok {
-		return
+	var pcs [1]uintptr
+	runtime.Callers(skip+1, pcs[:])
+
+	l.write(Entry{Time: time.Now(), Level: level, PC: pcs[0], Message: msg, Fields: fields})
+}
+
+// enabled returns if logger is logging at the level
+func (l *Logger) enabled(level LogLevel) bool {
+	if l.logClosed {
+		return false
 	}
 
-	logTime := ""
-	now := time.Now()
-	if l.logFlag&(Ldate|Ltime|Lmicroseconds) != 0 {
-		if l.logFlag&LUTC != 0 {
-			now = now.UTC()
-		}
-		if l.logFlag&Ldate != 0 {
-			logTime += fmt.Sprintf("%s ", now.Format("2006-01-02"))
-		}
-		if l.logFlag&Ltime != 0 {
-			logTime += fmt.Sprintf("%s ", now.Format("15:04:05"))
-		}
-		if l.logFlag&Lmicroseconds != 0 {
-			logTime = fmt.Sprintf("%s.%d ", strings.TrimSpace(logTime), now.Nanosecond()/1e3)
-		}
+	if l.logLevel > level {
+		return false
 	}
 
-	logFile := ""
-	if l.logFlag&(Llongfile|Lshortfile) != 0 {
-		_, file, line, ok := runtime.Caller(2)
-		if !ok {
-			logFile = "???:? "
-		} else {
-			logFile = fmt.Sprintf("%s:%d ", file, line)
-		}
-		if l.logFlag&Lshortfile != 0 {
-			ls := strings.Split(logFile, "/")
-			logFile = ls[len(ls)-1]
+	_, ok := levelMap[level]
+
+	return ok
+}
+
+// write format the entry and push to queue, or pass to slog handler if set
+func (l *Logger) write(e Entry) {
+	l.RLock()
+	flag, formatter, handler := l.logFlag, l.logFormatter, l.logHandler
+	l.RUnlock()
+
+	e.Flag = flag
+	if flag&LUTC != 0 {
+		e.Time = e.Time.UTC()
+	}
+
+	e.Caller = "???:?"
+	if e.PC != 0 {
+		f, _ := runtime.CallersFrames([]uintptr{e.PC}).Next()
+		if f.File != "" {
+			e.Caller = fmt.Sprintf("%s:%d", f.File, f.Line)
 		}
 	}
 
-	str := fmt.Sprintf("%s%s[%s] %s\n", logTime, logFile, levelMap[level], msg)
-	l.logQueue <- fmt.Sprintf(str, args...)
+	if handler != nil {
+		handleSlog(handler, e)
+		return
+	}
+
+	l.logQueue <- formatter.Format(e)
 }
 
 // LogOnce do log a msg only one times within one hour
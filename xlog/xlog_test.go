@@ -20,8 +20,10 @@
 package xlog
 
 import (
+	"bytes"
 	"os"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -204,3 +206,28 @@ func TestLogRotate(t *testing.T) {
 	wg.Wait()
 	log.Close()
 }
+
+// countStringer is Stringer counting the calls
+type countStringer struct {
+	n int32
+}
+
+// String returns the name and count the call
+func (c *countStringer) String() string {
+	atomic.AddInt32(&c.n, 1)
+	return "count"
+}
+
+func TestLogDisabled(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := New(buf, INFO)
+
+	// message of disabled level is not formatted
+	c := &countStringer{}
+	log.Debug("This is %s", c)
+	log.Log(99, "This is %s", c)
+	log.Close()
+	log.Info("This is %s", c)
+	assert.Equal(t, atomic.LoadInt32(&c.n), int32(0))
+	assert.Equal(t, buf.Len(), 0)
+}